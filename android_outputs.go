@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/bitrise-io/go-utils/ziputil"
+)
+
+// findMappingFiles returns the R8/ProGuard mapping files (build/outputs/mapping/<variant>/mapping.txt)
+// of the build variants matching the configuration, for example release, freeRelease or paidRelease.
+func findMappingFiles(androidOutputDir, configuration string, filter buildProductFilter) ([]string, error) {
+	var matches []string
+	if walkErr := filepath.Walk(androidOutputDir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			log.Warnf("Failed to read %s: %s", pth, err)
+			return nil
+		}
+
+		if info.IsDir() || info.Name() != "mapping.txt" {
+			return nil
+		}
+
+		variantDir := filepath.Dir(pth)
+		if filepath.Base(filepath.Dir(variantDir)) != "mapping" {
+			return nil
+		}
+
+		variant := strings.ToLower(filepath.Base(variantDir))
+		if strings.HasSuffix(variant, strings.ToLower(configuration)) && filter.IsBuildProduct(pth, info) {
+			matches = append(matches, pth)
+		}
+		return nil
+	}); walkErr != nil {
+		return nil, walkErr
+	}
+	return matches, nil
+}
+
+const nativeDebugSymbolsFileName = "native-debug-symbols.zip"
+
+// findNativeLibDirs returns the merged native library dirs (build/intermediates/merged_native_libs/<variant>)
+// of the build variants matching the configuration
+func findNativeLibDirs(androidOutputDir, configuration string) ([]string, error) {
+	var dirs []string
+	for _, pattern := range []string{
+		filepath.Join(androidOutputDir, "build", "intermediates", "merged_native_libs", "*"),
+		filepath.Join(androidOutputDir, "*", "build", "intermediates", "merged_native_libs", "*"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			variant := strings.ToLower(filepath.Base(match))
+			if strings.HasSuffix(variant, strings.ToLower(configuration)) {
+				dirs = append(dirs, match)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// nativeDebugSymbols returns the unstripped native libraries of a merged native library dir produced by the build,
+// keyed by their path in the symbols zip: <abi>/<library>.so
+func nativeDebugSymbols(nativeLibDir string, filter buildProductFilter) (map[string]string, error) {
+	libs := map[string]string{}
+	if walkErr := filepath.Walk(nativeLibDir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			log.Warnf("Failed to read %s: %s", pth, err)
+			return nil
+		}
+
+		if info.IsDir() || filepath.Ext(pth) != ".so" {
+			return nil
+		}
+
+		// for example out/lib/arm64-v8a/libsqlc-native-driver.so
+		abiDir := filepath.Dir(pth)
+		abi := filepath.Base(abiDir)
+		if filepath.Base(filepath.Dir(abiDir)) != "lib" || !sliceutil.IsStringInSlice(abi, androidABIs) {
+			return nil
+		}
+		if !filter.IsBuildProduct(pth, info) {
+			return nil
+		}
+
+		libs[filepath.Join(abi, info.Name())] = pth
+		return nil
+	}); walkErr != nil {
+		return nil, walkErr
+	}
+	return libs, nil
+}
+
+// zipNativeDebugSymbols packages the native libraries in the layout Google Play expects:
+// one directory per ABI in the root of the zip
+func zipNativeDebugSymbols(libs map[string]string, zipPth string) error {
+	stageDir, err := os.MkdirTemp("", "native-debug-symbols")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(stageDir); err != nil {
+			log.Warnf("Failed to remove %s: %s", stageDir, err)
+		}
+	}()
+
+	for relPth, libPth := range libs {
+		stagedPth := filepath.Join(stageDir, relPth)
+		if err := os.MkdirAll(filepath.Dir(stagedPth), 0700); err != nil {
+			return err
+		}
+		if err := command.CopyFile(libPth, stagedPth); err != nil {
+			return err
+		}
+	}
+
+	return ziputil.ZipDir(stageDir, zipPth, true)
+}
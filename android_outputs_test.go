@@ -0,0 +1,77 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_findMappingFiles(t *testing.T) {
+	androidOutputDir := t.TempDir()
+	outputsDir := filepath.Join(androidOutputDir, "app", "build", "outputs")
+	for _, variant := range []string{"release", "freeRelease", "debug", "freeDebug"} {
+		writeTestFile(t, filepath.Join(outputsDir, "mapping", variant, "mapping.txt"), "mapping")
+		writeTestFile(t, filepath.Join(outputsDir, "mapping", variant, "seeds.txt"), "seeds")
+	}
+	writeTestFile(t, filepath.Join(outputsDir, "logs", "release", "mapping.txt"), "not a mapping dir")
+
+	filter := modTimeFilter{buildStart: time.Now().Add(-time.Minute)}
+	got, err := findMappingFiles(androidOutputDir, "release", filter)
+	if err != nil {
+		t.Fatalf("findMappingFiles() error = %v", err)
+	}
+	sort.Strings(got)
+
+	want := []string{
+		filepath.Join(outputsDir, "mapping", "freeRelease", "mapping.txt"),
+		filepath.Join(outputsDir, "mapping", "release", "mapping.txt"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findMappingFiles() = %v, want %v", got, want)
+	}
+
+	got, err = findMappingFiles(androidOutputDir, "release", modTimeFilter{buildStart: time.Now().Add(time.Hour)})
+	if err != nil || len(got) != 0 {
+		t.Errorf("findMappingFiles() of a previous build = %v, %v", got, err)
+	}
+}
+
+func Test_nativeDebugSymbols(t *testing.T) {
+	androidOutputDir := t.TempDir()
+	mergedNativeLibsDir := filepath.Join(androidOutputDir, "app", "build", "intermediates", "merged_native_libs")
+	releaseLibDir := filepath.Join(mergedNativeLibsDir, "release", "mergeReleaseNativeLibs", "out", "lib")
+	writeTestFile(t, filepath.Join(releaseLibDir, "arm64-v8a", "libsqlc.so"), "so")
+	writeTestFile(t, filepath.Join(releaseLibDir, "armeabi-v7a", "libsqlc.so"), "so")
+	writeTestFile(t, filepath.Join(releaseLibDir, "x86_64", "libsqlc.so"), "so")
+	writeTestFile(t, filepath.Join(releaseLibDir, "arm64-v8a", "README.txt"), "not a library")
+	writeTestFile(t, filepath.Join(mergedNativeLibsDir, "release", "unknown", "libother.so"), "not in an ABI dir")
+	writeTestFile(t, filepath.Join(mergedNativeLibsDir, "debug", "out", "lib", "x86", "libsqlc.so"), "so")
+
+	dirs, err := findNativeLibDirs(androidOutputDir, "release")
+	if err != nil {
+		t.Fatalf("findNativeLibDirs() error = %v", err)
+	}
+	if want := []string{filepath.Join(mergedNativeLibsDir, "release")}; !reflect.DeepEqual(dirs, want) {
+		t.Fatalf("findNativeLibDirs() = %v, want %v", dirs, want)
+	}
+
+	got, err := nativeDebugSymbols(dirs[0], modTimeFilter{buildStart: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("nativeDebugSymbols() error = %v", err)
+	}
+	want := map[string]string{
+		filepath.Join("arm64-v8a", "libsqlc.so"):   filepath.Join(releaseLibDir, "arm64-v8a", "libsqlc.so"),
+		filepath.Join("armeabi-v7a", "libsqlc.so"): filepath.Join(releaseLibDir, "armeabi-v7a", "libsqlc.so"),
+		filepath.Join("x86_64", "libsqlc.so"):      filepath.Join(releaseLibDir, "x86_64", "libsqlc.so"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nativeDebugSymbols() = %v, want %v", got, want)
+	}
+
+	got, err = nativeDebugSymbols(dirs[0], modTimeFilter{buildStart: time.Now().Add(time.Hour)})
+	if err != nil || len(got) != 0 {
+		t.Errorf("nativeDebugSymbols() of a previous build = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-cordova-archive/cordova"
+	"github.com/bitrise-steplib/steps-cordova-archive/semver"
+)
+
+// jdkRange is the supported JDK major version range of a cordova-android major version
+type jdkRange struct {
+	min int
+	max int
+}
+
+// cordovaAndroidJDKs lists the JDKs supported by the Android Gradle Plugin and Gradle versions of cordova-android
+var cordovaAndroidJDKs = map[int]jdkRange{
+	9:  {min: 8, max: 11},
+	10: {min: 11, max: 16},
+	11: {min: 11, max: 17},
+	12: {min: 11, max: 17},
+	13: {min: 17, max: 21},
+	14: {min: 17, max: 21},
+}
+
+// gradleMinimumForJDK lists the first Gradle version supporting running on a JDK major version
+var gradleMinimumForJDK = map[int]string{
+	11: "5.0",
+	16: "7.0",
+	17: "7.3",
+	18: "7.5",
+	19: "7.6",
+	20: "8.3",
+	21: "8.5",
+	22: "8.8",
+	23: "8.10",
+}
+
+var gradleDistributionVersionPattern = regexp.MustCompile(`gradle-([0-9][0-9.]*[0-9])(-[a-z0-9-]+)?-(all|bin)\.zip`)
+
+// javaVersionPattern matches the version printed by java -version, for example: openjdk version "17.0.8" 2023-07-18
+var javaVersionPattern = regexp.MustCompile(`version "([^"]+)"`)
+
+// javaCommand returns the java binary of the java home, or the java in the PATH if no java home is set
+func javaCommand(javaHome string) string {
+	if javaHome == "" {
+		return "java"
+	}
+	return filepath.Join(javaHome, "bin", "java")
+}
+
+// jdkMajorVersion returns the major version of the JDK, 1.8.0_352 is reported as 8
+func jdkMajorVersion(javaCmd string) (int, error) {
+	cmd := command.New(javaCmd, "-version")
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("$ %s failed, output: %s, error: %s", cmd.PrintableCommandArgs(), out, err)
+	}
+	return parseJavaMajorVersion(out)
+}
+
+func parseJavaMajorVersion(javaVersionOutput string) (int, error) {
+	match := javaVersionPattern.FindStringSubmatch(javaVersionOutput)
+	if match == nil {
+		return 0, fmt.Errorf("no java version found in: %s", javaVersionOutput)
+	}
+
+	components := strings.FieldsFunc(match[1], func(r rune) bool { return r == '.' || r == '_' || r == '-' || r == '+' })
+	if len(components) > 1 && components[0] == "1" {
+		components = components[1:]
+	}
+	major, err := strconv.Atoi(components[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid java version: %s", match[1])
+	}
+	return major, nil
+}
+
+// androidGradleVersion returns the Gradle version the Android platform builds with:
+// the version of the Gradle wrapper distribution, or the GRADLE_VERSION of cdv-gradle-config.json.
+// Returns an empty string if it is not known.
+func androidGradleVersion(androidDir string) (string, error) {
+	if f, err := os.Open(filepath.Join(androidDir, "gradle", "wrapper", "gradle-wrapper.properties")); err == nil {
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Warnf("Failed to close gradle-wrapper.properties: %s", err)
+			}
+		}()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "distributionUrl") {
+				continue
+			}
+			if match := gradleDistributionVersionPattern.FindStringSubmatch(line); match != nil {
+				return match[1], nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return "", err
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	gradleConfig, err := readCdvGradleConfig(androidDir)
+	if err != nil {
+		return "", err
+	}
+	if version, ok := gradleConfig["GRADLE_VERSION"].(string); ok {
+		return version, nil
+	}
+	return "", nil
+}
+
+// readCdvGradleConfig returns the build settings cordova-android (9+) writes to the cdv-gradle-config.json of the Android platform,
+// the settings are empty if the file does not exist
+func readCdvGradleConfig(androidDir string) (map[string]interface{}, error) {
+	gradleConfig := map[string]interface{}{}
+	content, err := os.ReadFile(filepath.Join(androidDir, "cdv-gradle-config.json"))
+	if os.IsNotExist(err) {
+		return gradleConfig, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &gradleConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse cdv-gradle-config.json: %s", err)
+	}
+	return gradleConfig, nil
+}
+
+// checkJDKCompatibility checks the JDK against the cordova-android version and the Gradle version.
+// A JDK older than the one required by cordova-android is an error, other incompatibilities are returned as warnings.
+func checkJDKCompatibility(jdkMajor int, cordovaAndroidVersion *semver.Version, gradleVersion string) ([]string, error) {
+	var warnings []string
+	if cordovaAndroidVersion != nil {
+		if supported, ok := cordovaAndroidJDKs[cordovaAndroidVersion.Major]; ok {
+			if jdkMajor < supported.min {
+				return nil, fmt.Errorf("cordova-android %s requires JDK %d or later, the JDK in use is %d", cordovaAndroidVersion, supported.min, jdkMajor)
+			}
+			if jdkMajor > supported.max {
+				warnings = append(warnings, fmt.Sprintf("cordova-android %s supports JDK %d to %d, the JDK in use is %d", cordovaAndroidVersion, supported.min, supported.max, jdkMajor))
+			}
+		}
+	}
+
+	if gradleVersion != "" {
+		gradle, err := semver.Parse(gradleVersion)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Unknown Gradle version: %s", gradleVersion))
+			return warnings, nil
+		}
+
+		required, requiredJDK := "", 0
+		for jdk, minimum := range gradleMinimumForJDK {
+			if jdk <= jdkMajor && jdk > requiredJDK {
+				required, requiredJDK = minimum, jdk
+			}
+		}
+		if required != "" && gradle.LessThan(semver.MustParse(required)) {
+			warnings = append(warnings, fmt.Sprintf("Gradle %s does not support JDK %d, Gradle %s or later is required", gradleVersion, jdkMajor, required))
+		}
+	}
+	return warnings, nil
+}
+
+// javaHomeEnvs returns the environment using the given java home, for the compile command
+func javaHomeEnvs(javaHome string) []string {
+	return []string{
+		"JAVA_HOME=" + javaHome,
+		"PATH=" + filepath.Join(javaHome, "bin") + string(os.PathListSeparator) + os.Getenv("PATH"),
+	}
+}
+
+// androidJDKPreflight checks the JDK (of the java home, or of the environment) the Android platform will be compiled with
+func androidJDKPreflight(workDir, javaHome string) error {
+	if javaHome == "" {
+		javaHome = os.Getenv("JAVA_HOME")
+	}
+
+	jdkMajor, err := jdkMajorVersion(javaCommand(javaHome))
+	if err != nil {
+		log.Warnf("Failed to get the JDK version: %s", err)
+		return nil
+	}
+	if javaHome != "" {
+		log.Printf("JDK: %d (%s)", jdkMajor, javaHome)
+	} else {
+		log.Printf("JDK: %d", jdkMajor)
+	}
+
+	cordovaAndroidVersion, err := cordova.PlatformVersion(workDir, "android")
+	if err != nil {
+		log.Warnf("Failed to detect cordova-android version: %s", err)
+	}
+	if cordovaAndroidVersion != nil {
+		log.Printf("cordova-android: %s", cordovaAndroidVersion)
+	}
+
+	gradleVersion, err := androidGradleVersion(filepath.Join(workDir, "platforms", "android"))
+	if err != nil {
+		log.Warnf("Failed to detect the Gradle version: %s", err)
+	}
+	if gradleVersion != "" {
+		log.Printf("Gradle: %s", gradleVersion)
+	}
+
+	warnings, err := checkJDKCompatibility(jdkMajor, cordovaAndroidVersion, gradleVersion)
+	for _, warning := range warnings {
+		log.Warnf("%s", warning)
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/semver"
+)
+
+func Test_parseJavaMajorVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "JDK 8",
+			output: "openjdk version \"1.8.0_352\"\nOpenJDK Runtime Environment (build 1.8.0_352-b08)",
+			want:   8,
+		},
+		{
+			name:   "JDK 17",
+			output: "openjdk version \"17.0.8\" 2023-07-18\nOpenJDK Runtime Environment Temurin-17.0.8+7 (build 17.0.8+7)",
+			want:   17,
+		},
+		{
+			name:   "major only",
+			output: `java version "21" 2023-09-19 LTS`,
+			want:   21,
+		},
+		{
+			name:   "early access",
+			output: `openjdk version "22-ea" 2024-03-19`,
+			want:   22,
+		},
+		{
+			name:    "no version",
+			output:  "java: command not found",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJavaMajorVersion(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJavaMajorVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseJavaMajorVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_androidGradleVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "gradle wrapper",
+			files: map[string]string{
+				"gradle/wrapper/gradle-wrapper.properties": "distributionBase=GRADLE_USER_HOME\ndistributionUrl=https\\://services.gradle.org/distributions/gradle-8.7-all.zip\n",
+				"cdv-gradle-config.json":                   `{"GRADLE_VERSION": "8.3"}`,
+			},
+			want: "8.7",
+		},
+		{
+			name: "gradle wrapper milestone",
+			files: map[string]string{
+				"gradle/wrapper/gradle-wrapper.properties": "distributionUrl=https\\://services.gradle.org/distributions/gradle-8.10.2-milestone-1-bin.zip\n",
+			},
+			want: "8.10.2",
+		},
+		{
+			name: "cdv-gradle-config.json",
+			files: map[string]string{
+				"cdv-gradle-config.json": `{"MIN_SDK_VERSION": 24, "GRADLE_VERSION": "8.7"}`,
+			},
+			want: "8.7",
+		},
+		{
+			name: "unknown",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			androidDir := t.TempDir()
+			for pth, content := range tt.files {
+				writeTestFile(t, filepath.Join(androidDir, pth), content)
+			}
+
+			got, err := androidGradleVersion(androidDir)
+			if err != nil {
+				t.Fatalf("androidGradleVersion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("androidGradleVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkJDKCompatibility(t *testing.T) {
+	version := func(s string) *semver.Version {
+		v := semver.MustParse(s)
+		return &v
+	}
+
+	tests := []struct {
+		name                  string
+		jdkMajor              int
+		cordovaAndroidVersion *semver.Version
+		gradleVersion         string
+		wantWarnings          []string
+		wantErr               bool
+	}{
+		{
+			name:                  "supported",
+			jdkMajor:              17,
+			cordovaAndroidVersion: version("13.0.0"),
+			gradleVersion:         "8.7",
+		},
+		{
+			name:                  "JDK too old",
+			jdkMajor:              11,
+			cordovaAndroidVersion: version("13.0.0"),
+			gradleVersion:         "8.7",
+			wantErr:               true,
+		},
+		{
+			name:                  "JDK newer than supported",
+			jdkMajor:              21,
+			cordovaAndroidVersion: version("12.0.1"),
+			gradleVersion:         "8.5",
+			wantWarnings:          []string{"cordova-android 12.0.1 supports JDK 11 to 17, the JDK in use is 21"},
+		},
+		{
+			name:          "Gradle does not support the JDK",
+			jdkMajor:      21,
+			gradleVersion: "7.6",
+			wantWarnings:  []string{"Gradle 7.6 does not support JDK 21, Gradle 8.5 or later is required"},
+		},
+		{
+			name:                  "unknown cordova-android major",
+			jdkMajor:              8,
+			cordovaAndroidVersion: version("8.1.0"),
+		},
+		{
+			name:          "Gradle release candidate",
+			jdkMajor:      17,
+			gradleVersion: "8.7-rc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkJDKCompatibility(tt.jdkMajor, tt.cordovaAndroidVersion, tt.gradleVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkJDKCompatibility() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.wantWarnings) {
+				t.Errorf("checkJDKCompatibility() = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
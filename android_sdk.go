@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// androidSDKRequirements are the Android SDK components the Android platform compiles with
+type androidSDKRequirements struct {
+	// compileSDK is the SDK platform, for example android-34
+	compileSDK string
+	// buildTools is the build-tools version, for example 34.0.0
+	buildTools string
+}
+
+var (
+	defaultCompileSDKPattern = regexp.MustCompile(`defaultCompileSdkVersion\s*=\s*"?([0-9A-Za-z-]+)"?`)
+	defaultBuildToolsPattern = regexp.MustCompile(`defaultBuildToolsVersion\s*=\s*"([^"]+)"`)
+)
+
+// androidSDKDir returns the Android SDK location set by ANDROID_HOME, or by the deprecated ANDROID_SDK_ROOT
+func androidSDKDir() string {
+	for _, key := range []string{"ANDROID_HOME", "ANDROID_SDK_ROOT"} {
+		if dir := os.Getenv(key); dir != "" {
+			return dir
+		}
+	}
+	return ""
+}
+
+// readProperties returns the key-value pairs of a Java properties file
+func readProperties(pth string) (map[string]string, error) {
+	f, err := os.Open(pth)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", pth, err)
+		}
+	}()
+
+	properties := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			continue
+		}
+		properties[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+	return properties, scanner.Err()
+}
+
+// cdvGradleConfigValue returns a value of the cdv-gradle-config.json as a string, numbers are formatted without decimals
+func cdvGradleConfigValue(gradleConfig map[string]interface{}, key string) string {
+	switch value := gradleConfig[key].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return ""
+}
+
+// sdkPlatformName returns the SDK platform of a compile SDK version: 34 and android-34 are both android-34
+func sdkPlatformName(compileSDK string) string {
+	if compileSDK == "" || strings.HasPrefix(compileSDK, "android-") {
+		return compileSDK
+	}
+	return "android-" + compileSDK
+}
+
+// androidSDKRequirementsOf returns the compile SDK and build-tools versions of the Android platform, in the order cordova-android resolves them:
+// the cdvCompileSdkVersion and cdvBuildToolsVersion of gradle.properties, the cdv-gradle-config.json (cordova-android 9+),
+// the target of project.properties and the defaults of build.gradle (cordova-android 8 and earlier).
+func androidSDKRequirementsOf(androidDir string) (androidSDKRequirements, error) {
+	var requirements androidSDKRequirements
+
+	gradleProperties, err := readProperties(filepath.Join(androidDir, "gradle.properties"))
+	if err != nil {
+		return requirements, err
+	}
+	requirements.compileSDK = gradleProperties["cdvCompileSdkVersion"]
+	requirements.buildTools = gradleProperties["cdvBuildToolsVersion"]
+
+	gradleConfig, err := readCdvGradleConfig(androidDir)
+	if err != nil {
+		return requirements, err
+	}
+	if requirements.compileSDK == "" {
+		requirements.compileSDK = cdvGradleConfigValue(gradleConfig, "COMPILE_SDK_VERSION")
+	}
+	if requirements.compileSDK == "" {
+		requirements.compileSDK = cdvGradleConfigValue(gradleConfig, "SDK_VERSION")
+	}
+	if requirements.buildTools == "" {
+		requirements.buildTools = cdvGradleConfigValue(gradleConfig, "BUILD_TOOLS_VERSION")
+	}
+
+	if requirements.compileSDK == "" {
+		projectProperties, err := readProperties(filepath.Join(androidDir, "project.properties"))
+		if err != nil {
+			return requirements, err
+		}
+		requirements.compileSDK = projectProperties["target"]
+	}
+
+	if requirements.compileSDK == "" || requirements.buildTools == "" {
+		content, err := os.ReadFile(filepath.Join(androidDir, "build.gradle"))
+		if err != nil && !os.IsNotExist(err) {
+			return requirements, err
+		}
+		if match := defaultCompileSDKPattern.FindSubmatch(content); match != nil && requirements.compileSDK == "" {
+			requirements.compileSDK = string(match[1])
+		}
+		if match := defaultBuildToolsPattern.FindSubmatch(content); match != nil && requirements.buildTools == "" {
+			requirements.buildTools = string(match[1])
+		}
+	}
+
+	requirements.compileSDK = sdkPlatformName(requirements.compileSDK)
+	return requirements, nil
+}
+
+// missingSDKPackages returns the sdkmanager packages of the requirements, which are not installed in the SDK
+func missingSDKPackages(sdkDir string, requirements androidSDKRequirements) ([]string, error) {
+	var missing []string
+	for _, component := range []struct {
+		dir     string
+		version string
+	}{{"platforms", requirements.compileSDK}, {"build-tools", requirements.buildTools}} {
+		if component.version == "" {
+			continue
+		}
+
+		if exist, err := pathutil.IsDirExists(filepath.Join(sdkDir, component.dir, component.version)); err != nil {
+			return nil, err
+		} else if !exist {
+			missing = append(missing, component.dir+";"+component.version)
+		}
+	}
+	return missing, nil
+}
+
+// androidSDKPreflight checks if the SDK platform and build-tools the Android platform compiles with are installed.
+// Missing components are reported as warnings, as the Android Gradle Plugin downloads them if the SDK licenses are accepted.
+func androidSDKPreflight(workDir string) {
+	sdkDir := androidSDKDir()
+	if sdkDir == "" {
+		log.Warnf("Neither ANDROID_HOME nor ANDROID_SDK_ROOT is set, skipping the Android SDK check")
+		return
+	}
+	if exist, err := pathutil.IsDirExists(sdkDir); err != nil {
+		log.Warnf("Failed to check if the Android SDK (%s) exists: %s", sdkDir, err)
+		return
+	} else if !exist {
+		log.Warnf("The Android SDK (%s) does not exist", sdkDir)
+		return
+	}
+	log.Printf("Android SDK: %s", sdkDir)
+
+	requirements, err := androidSDKRequirementsOf(filepath.Join(workDir, "platforms", "android"))
+	if err != nil {
+		log.Warnf("Failed to read the Android SDK requirements of the Android platform: %s", err)
+		return
+	}
+	if requirements.compileSDK != "" {
+		log.Printf("Compile SDK: %s", requirements.compileSDK)
+	}
+	if requirements.buildTools != "" {
+		log.Printf("Build tools: %s", requirements.buildTools)
+	}
+
+	missing, err := missingSDKPackages(sdkDir, requirements)
+	if err != nil {
+		log.Warnf("Failed to check the installed Android SDK packages: %s", err)
+		return
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	quoted := make([]string, len(missing))
+	for i, pkg := range missing {
+		quoted[i] = fmt.Sprintf("%q", pkg)
+	}
+	log.Warnf("Missing Android SDK packages: %s", strings.Join(missing, ", "))
+	log.Warnf("Install them with: sdkmanager %s", strings.Join(quoted, " "))
+}
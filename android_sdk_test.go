@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_androidSDKRequirementsOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  androidSDKRequirements
+	}{
+		{
+			name: "cdv-gradle-config.json",
+			files: map[string]string{
+				"cdv-gradle-config.json": `{"SDK_VERSION": 34, "COMPILE_SDK_VERSION": null, "BUILD_TOOLS_VERSION": "34.0.0"}`,
+			},
+			want: androidSDKRequirements{compileSDK: "android-34", buildTools: "34.0.0"},
+		},
+		{
+			name: "compile SDK version of cdv-gradle-config.json",
+			files: map[string]string{
+				"cdv-gradle-config.json": `{"SDK_VERSION": 34, "COMPILE_SDK_VERSION": 35, "BUILD_TOOLS_VERSION": "35.0.0"}`,
+			},
+			want: androidSDKRequirements{compileSDK: "android-35", buildTools: "35.0.0"},
+		},
+		{
+			name: "gradle.properties overrides",
+			files: map[string]string{
+				"gradle.properties":      "org.gradle.jvmargs=-Xmx2048m\ncdvCompileSdkVersion=android-33\ncdvBuildToolsVersion = 33.0.2\n",
+				"cdv-gradle-config.json": `{"SDK_VERSION": 34, "BUILD_TOOLS_VERSION": "34.0.0"}`,
+			},
+			want: androidSDKRequirements{compileSDK: "android-33", buildTools: "33.0.2"},
+		},
+		{
+			name: "project.properties and build.gradle",
+			files: map[string]string{
+				"project.properties": "# Project target.\ntarget=android-28\nandroid.library.reference.1=CordovaLib\n",
+				"build.gradle":       "project.ext {\n  defaultBuildToolsVersion=\"28.0.3\" //String\n  defaultCompileSdkVersion=28 //Integer\n}\n",
+			},
+			want: androidSDKRequirements{compileSDK: "android-28", buildTools: "28.0.3"},
+		},
+		{
+			name: "build.gradle",
+			files: map[string]string{
+				"build.gradle": "project.ext {\n  defaultBuildToolsVersion=\"29.0.2\"\n  defaultCompileSdkVersion=29\n}\n",
+			},
+			want: androidSDKRequirements{compileSDK: "android-29", buildTools: "29.0.2"},
+		},
+		{
+			name: "unknown",
+			want: androidSDKRequirements{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			androidDir := t.TempDir()
+			for pth, content := range tt.files {
+				writeTestFile(t, filepath.Join(androidDir, pth), content)
+			}
+
+			got, err := androidSDKRequirementsOf(androidDir)
+			if err != nil {
+				t.Fatalf("androidSDKRequirementsOf() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("androidSDKRequirementsOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_missingSDKPackages(t *testing.T) {
+	sdkDir := t.TempDir()
+	for _, dir := range []string{"platforms/android-34", "build-tools/34.0.0"} {
+		if err := os.MkdirAll(filepath.Join(sdkDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		requirements androidSDKRequirements
+		want         []string
+	}{
+		{
+			name:         "installed",
+			requirements: androidSDKRequirements{compileSDK: "android-34", buildTools: "34.0.0"},
+		},
+		{
+			name:         "missing",
+			requirements: androidSDKRequirements{compileSDK: "android-35", buildTools: "35.0.0"},
+			want:         []string{"platforms;android-35", "build-tools;35.0.0"},
+		},
+		{
+			name:         "missing build-tools",
+			requirements: androidSDKRequirements{compileSDK: "android-34", buildTools: "34.0.1"},
+			want:         []string{"build-tools;34.0.1"},
+		},
+		{
+			name:         "unknown requirements",
+			requirements: androidSDKRequirements{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := missingSDKPackages(sdkDir, tt.requirements)
+			if err != nil {
+				t.Fatalf("missingSDKPackages() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missingSDKPackages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
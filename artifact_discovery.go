@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// Artifact discovery modes
+const (
+	// snapshotDiscovery compares the platforms' build output dirs before and after the compile
+	snapshotDiscovery = "snapshot"
+	// modTimeDiscovery collects artifacts modified after the compile started
+	modTimeDiscovery = "mtime"
+)
+
+// buildProductFilter decides whether an artifact found in the output dirs was produced by the current build
+type buildProductFilter interface {
+	IsBuildProduct(pth string, info os.FileInfo) bool
+}
+
+type modTimeFilter struct {
+	buildStart time.Time
+}
+
+func (f modTimeFilter) IsBuildProduct(_ string, info os.FileInfo) bool {
+	return !info.ModTime().Before(f.buildStart)
+}
+
+// snapshotFilter accepts new or changed files, and dirs (like .app and .dSYM) containing new or changed files
+type snapshotFilter struct {
+	changed map[string]bool
+}
+
+func newSnapshotFilter(before, after outputSnapshot) snapshotFilter {
+	changed := map[string]bool{}
+	for _, pth := range before.changedFiles(after) {
+		for dir := pth; !changed[dir]; dir = filepath.Dir(dir) {
+			changed[dir] = true
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+	return snapshotFilter{changed: changed}
+}
+
+func (f snapshotFilter) IsBuildProduct(pth string, _ os.FileInfo) bool {
+	return f.changed[pth]
+}
+
+type fileFingerprint struct {
+	size int64
+	hash string
+}
+
+// outputSnapshot indexes the files of the build output dirs by path
+type outputSnapshot map[string]fileFingerprint
+
+// outputSnapshotRoots returns the platforms' build output dirs:
+// platforms/*/build* (for example platforms/ios/build), the Android app module's platforms/*/*/build/outputs
+// and its merged native libraries (platforms/*/*/build/intermediates/merged_native_libs), exported as native debug symbols
+func outputSnapshotRoots(workDir string) ([]string, error) {
+	var roots []string
+	for _, pattern := range []string{
+		filepath.Join(workDir, "platforms", "*", "build*"),
+		filepath.Join(workDir, "platforms", "*", "*", "build", "outputs"),
+		filepath.Join(workDir, "platforms", "*", "*", "build", "intermediates", "merged_native_libs"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, matches...)
+	}
+	return roots, nil
+}
+
+// takeOutputSnapshot indexes the files under the given roots.
+// If previous is given, only files with a matching previous size are hashed,
+// the others are changed anyway.
+// Unreadable entries are logged and left out of the snapshot.
+func takeOutputSnapshot(roots []string, previous outputSnapshot) outputSnapshot {
+	snapshot := outputSnapshot{}
+	for _, root := range roots {
+		if err := filepath.Walk(root, func(pth string, info os.FileInfo, err error) error {
+			if err != nil {
+				log.Warnf("Failed to read %s: %s", pth, err)
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			fingerprint := fileFingerprint{size: info.Size()}
+			if previous == nil || previous[pth].size == info.Size() {
+				hash, err := fileSHA256(pth)
+				if err != nil {
+					log.Warnf("Failed to hash %s: %s", pth, err)
+					return nil
+				}
+				fingerprint.hash = hash
+			}
+
+			snapshot[pth] = fingerprint
+			return nil
+		}); err != nil {
+			log.Warnf("Failed to walk %s: %s", root, err)
+		}
+	}
+	return snapshot
+}
+
+// changedFiles returns the files of after, which are missing from or differ in the snapshot
+func (s outputSnapshot) changedFiles(after outputSnapshot) []string {
+	var changed []string
+	for pth, fingerprint := range after {
+		if previous, ok := s[pth]; !ok || previous != fingerprint {
+			changed = append(changed, pth)
+		}
+	}
+	return changed
+}
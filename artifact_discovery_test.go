@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t *testing.T, pth, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(pth), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pth, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_snapshotFilter(t *testing.T) {
+	workDir := t.TempDir()
+	iosBuildDir := filepath.Join(workDir, "platforms", "ios", "build", "Release-iphoneos")
+	androidOutputDir := filepath.Join(workDir, "platforms", "android", "app", "build", "outputs")
+
+	unchangedIpa := filepath.Join(iosBuildDir, "Old.ipa")
+	rebuiltIpa := filepath.Join(iosBuildDir, "App.ipa")
+	rebuiltApk := filepath.Join(androidOutputDir, "apk", "release", "app-release.apk")
+	writeTestFile(t, unchangedIpa, "old")
+	writeTestFile(t, rebuiltIpa, "ipa v1")
+	writeTestFile(t, rebuiltApk, "apk v1")
+	writeTestFile(t, filepath.Join(iosBuildDir, "App.app", "App"), "binary")
+	writeTestFile(t, filepath.Join(iosBuildDir, "App.app.dSYM", "Contents", "Info.plist"), "plist")
+	nativeLibDir := filepath.Join(workDir, "platforms", "android", "app", "build", "intermediates", "merged_native_libs", "release", "out", "lib", "arm64-v8a")
+	staleLib := filepath.Join(nativeLibDir, "libstale.so")
+	rebuiltLib := filepath.Join(nativeLibDir, "libsqlc.so")
+	writeTestFile(t, staleLib, "so")
+	writeTestFile(t, rebuiltLib, "so v1")
+
+	roots, err := outputSnapshotRoots(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := takeOutputSnapshot(roots, nil)
+
+	// same size, different content; mtime is preserved
+	modTime := time.Now().Add(-time.Hour)
+	writeTestFile(t, rebuiltIpa, "ipa v2")
+	if err := os.Chtimes(rebuiltIpa, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, rebuiltApk, "apk v2 with new size")
+	writeTestFile(t, filepath.Join(iosBuildDir, "App.app", "www", "index.html"), "new")
+	newAab := filepath.Join(androidOutputDir, "bundle", "release", "app-release.aab")
+	writeTestFile(t, newAab, "aab")
+	writeTestFile(t, rebuiltLib, "so v2")
+
+	roots, err = outputSnapshotRoots(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := newSnapshotFilter(before, takeOutputSnapshot(roots, before))
+
+	tests := []struct {
+		pth  string
+		want bool
+	}{
+		{unchangedIpa, false},
+		{rebuiltIpa, true},
+		{rebuiltApk, true},
+		{newAab, true},
+		{filepath.Join(iosBuildDir, "App.app"), true},
+		{filepath.Join(iosBuildDir, "App.app.dSYM"), false},
+		{staleLib, false},
+		{rebuiltLib, true},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.pth), func(t *testing.T) {
+			if got := filter.IsBuildProduct(tt.pth, nil); got != tt.want {
+				t.Errorf("IsBuildProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_findArtifact(t *testing.T) {
+	rootDir := t.TempDir()
+	writeTestFile(t, filepath.Join(rootDir, "release", "app-release.apk"), "apk")
+	writeTestFile(t, filepath.Join(rootDir, "debug", "app-debug.apk"), "apk")
+
+	unreadableDir := filepath.Join(rootDir, "unreadable")
+	writeTestFile(t, filepath.Join(unreadableDir, "app.apk"), "apk")
+	if err := os.Chmod(unreadableDir, 0000); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chmod(unreadableDir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	got, err := findArtifact(rootDir, "apk", modTimeFilter{buildStart: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("findArtifact() error = %v", err)
+	}
+	sort.Strings(got)
+
+	want := []string{
+		filepath.Join(rootDir, "debug", "app-debug.apk"),
+		filepath.Join(rootDir, "release", "app-release.apk"),
+	}
+	if os.Geteuid() == 0 {
+		// root can read the unreadable dir
+		want = append(want, filepath.Join(unreadableDir, "app.apk"))
+		sort.Strings(want)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findArtifact() = %v, want %v", got, want)
+	}
+
+	got, err = findArtifact(filepath.Join(rootDir, "missing"), "apk", modTimeFilter{})
+	if err != nil || len(got) != 0 {
+		t.Errorf("findArtifact() on missing dir = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/cordova"
+)
+
+const manifestFileName = "cordova-archive-manifest.json"
+
+// Artifact types
+const (
+	ipaArtifact     = "ipa"
+	appArtifact     = "app"
+	dsymArtifact    = "dsym"
+	apkArtifact     = "apk"
+	aabArtifact     = "aab"
+	mappingArtifact = "mapping"
+
+	nativeDebugSymbolsArtifact = "native_debug_symbols"
+)
+
+// artifactAppMetadata identifies the app an artifact was built from
+type artifactAppMetadata struct {
+	AppID       string          `json:"app_id"`
+	Version     string          `json:"version"`
+	BuildNumber string          `json:"build_number"`
+	IOSApp      *iosAppMetadata `json:"ios_app,omitempty"`
+}
+
+// manifestArtifact describes an artifact exported to the deploy dir
+type manifestArtifact struct {
+	Platform      string              `json:"platform"`
+	Type          string              `json:"type"`
+	Configuration string              `json:"configuration"`
+	Target        string              `json:"target"`
+	SourcePath    string              `json:"source_path"`
+	DeployPath    string              `json:"deploy_path"`
+	Size          int64               `json:"size"`
+	SHA256        string              `json:"sha256"`
+	App           artifactAppMetadata `json:"app"`
+
+	// bundlePth is the deploy dir path of the .ipa or .app the artifact contains
+	bundlePth string
+}
+
+// artifactManifest collects the artifacts exported by the step
+type artifactManifest struct {
+	Artifacts []manifestArtifact `json:"artifacts"`
+
+	configuration string
+	target        string
+	config        cordova.Config
+}
+
+func newArtifactManifest(configuration, target string, config cordova.Config) *artifactManifest {
+	return &artifactManifest{
+		Artifacts:     []manifestArtifact{},
+		configuration: configuration,
+		target:        target,
+		config:        config,
+	}
+}
+
+// add records an exported file, bundlePth is the exported .ipa or .app dir the file contains, if any
+func (m *artifactManifest) add(platform, artifactType, sourcePth, deployPth, bundlePth, sha256 string) error {
+	info, err := os.Stat(deployPth)
+	if err != nil {
+		return err
+	}
+
+	m.Artifacts = append(m.Artifacts, manifestArtifact{
+		Platform:      platform,
+		Type:          artifactType,
+		Configuration: m.configuration,
+		Target:        m.target,
+		SourcePath:    sourcePth,
+		DeployPath:    deployPth,
+		Size:          info.Size(),
+		SHA256:        sha256,
+		App: artifactAppMetadata{
+			AppID:       m.config.AppID(platform),
+			Version:     m.config.Version,
+			BuildNumber: m.config.BuildNumber(platform),
+		},
+		bundlePth: bundlePth,
+	})
+	return nil
+}
+
+// setIOSAppMetadata attaches the metadata read from an exported .ipa or .app to the artifacts containing it
+func (m *artifactManifest) setIOSAppMetadata(metadata iosAppMetadata) {
+	for i, artifact := range m.Artifacts {
+		if artifact.bundlePth != metadata.Path {
+			continue
+		}
+
+		iosApp := metadata
+		m.Artifacts[i].App = artifactAppMetadata{
+			AppID:       metadata.BundleID,
+			Version:     metadata.Version,
+			BuildNumber: metadata.BuildNumber,
+			IOSApp:      &iosApp,
+		}
+	}
+}
+
+// write writes the manifest into the given dir
+func (m *artifactManifest) write(dir string) (string, error) {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	pth := filepath.Join(dir, manifestFileName)
+	if err := os.WriteFile(pth, content, 0600); err != nil {
+		return "", err
+	}
+	return pth, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/cordova"
+)
+
+func Test_artifactManifest(t *testing.T) {
+	deployDir := t.TempDir()
+	apkPth := filepath.Join(deployDir, "app-release.apk")
+	ipaPth := filepath.Join(deployDir, "App.ipa")
+	writeTestFile(t, apkPth, "apk")
+	writeTestFile(t, ipaPth, "ipa")
+
+	config := cordova.Config{ID: "io.bitrise.app", Version: "1.0.0", AndroidVersionCode: "7"}
+	manifest := newArtifactManifest("release", "device", config)
+	if err := manifest.add("android", apkArtifact, "/build/app-release.apk", apkPth, "", "dd37c2d7274f7ea982cb83390c36918fee9ce8889073c44b68cdc00bdb8c3e04"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.add("ios", ipaArtifact, "/build/App.ipa", ipaPth, ipaPth, ""); err != nil {
+		t.Fatal(err)
+	}
+	manifest.setIOSAppMetadata(iosAppMetadata{Path: ipaPth, BundleID: "io.bitrise.ios", Version: "1.0.1", BuildNumber: "12"})
+
+	pth, err := manifest.write(deployDir)
+	if err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Artifacts []manifestArtifact `json:"artifacts"`
+	}
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Artifacts) != 2 {
+		t.Fatalf("got %d artifacts, want 2", len(got.Artifacts))
+	}
+
+	apk := got.Artifacts[0]
+	if apk.Type != "apk" || apk.Platform != "android" || apk.Configuration != "release" || apk.Target != "device" ||
+		apk.SourcePath != "/build/app-release.apk" || apk.DeployPath != apkPth || apk.Size != 3 ||
+		apk.SHA256 != "dd37c2d7274f7ea982cb83390c36918fee9ce8889073c44b68cdc00bdb8c3e04" {
+		t.Errorf("unexpected apk artifact: %+v", apk)
+	}
+	if apk.App.AppID != "io.bitrise.app" || apk.App.BuildNumber != "7" || apk.App.IOSApp != nil {
+		t.Errorf("unexpected apk app metadata: %+v", apk.App)
+	}
+
+	ipa := got.Artifacts[1]
+	if ipa.App.AppID != "io.bitrise.ios" || ipa.App.Version != "1.0.1" || ipa.App.BuildNumber != "12" || ipa.App.IOSApp == nil {
+		t.Errorf("unexpected ipa app metadata: %+v", ipa.App)
+	}
+}
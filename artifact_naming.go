@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/cordova"
+)
+
+var (
+	namePlaceholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+	namePlaceholders       = []string{"{app_id}", "{version}", "{build_number}", "{configuration}", "{platform}", "{abi}", "{ext}"}
+
+	// longer ABI names first, as x86 is a prefix of x86_64
+	androidABIs = []string{"arm64-v8a", "armeabi-v7a", "armeabi", "x86_64", "x86", "mips64", "mips"}
+)
+
+const universalABI = "universal"
+
+// templatedArtifactExts lists the artifact types named by the artifact name template
+var templatedArtifactExts = []string{".apk", ".aab", ".ipa"}
+
+// artifactNamer picks the file names of the outputs copied to the deploy dir
+type artifactNamer struct {
+	template      string
+	config        cordova.Config
+	configuration string
+	// used holds the names already given and the files already in the deploy dir (exported by an earlier step or build),
+	// to avoid overwriting them
+	used map[string]bool
+}
+
+func newArtifactNamer(template string, config cordova.Config, configuration, deployDir string) (*artifactNamer, error) {
+	for _, placeholder := range namePlaceholderPattern.FindAllString(template, -1) {
+		if !isNamePlaceholder(placeholder) {
+			return nil, fmt.Errorf("unknown placeholder %s, available placeholders: %s", placeholder, strings.Join(namePlaceholders, ", "))
+		}
+	}
+
+	used := map[string]bool{}
+	entries, err := os.ReadDir(deployDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list the deploy dir: %s", err)
+	}
+	for _, entry := range entries {
+		used[entry.Name()] = true
+	}
+
+	return &artifactNamer{
+		template:      template,
+		config:        config,
+		configuration: configuration,
+		used:          used,
+	}, nil
+}
+
+func isNamePlaceholder(s string) bool {
+	for _, placeholder := range namePlaceholders {
+		if s == placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+// name returns the deploy dir file name of the given output.
+// Apks, aabs and ipas are named by the template (if set), other outputs keep their file name.
+// If the name is already given to an other output, or a file with the name is already in the deploy dir,
+// a -2, -3, ... suffix is added before the extension.
+func (n *artifactNamer) name(outputPth, platform string) string {
+	fileName := filepath.Base(outputPth)
+	ext := filepath.Ext(fileName)
+
+	if n.template != "" && isTemplatedArtifact(ext) {
+		fileName = n.render(fileName, platform)
+	}
+
+	name := fileName
+	for i := 2; n.used[name]; i++ {
+		base := strings.TrimSuffix(fileName, ext)
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	n.used[name] = true
+
+	return name
+}
+
+func (n *artifactNamer) render(fileName, platform string) string {
+	ext := strings.TrimPrefix(filepath.Ext(fileName), ".")
+	replacer := strings.NewReplacer(
+		"{app_id}", n.config.AppID(platform),
+		"{version}", n.config.Version,
+		"{build_number}", n.config.BuildNumber(platform),
+		"{configuration}", n.configuration,
+		"{platform}", platform,
+		"{abi}", abiFromFileName(fileName),
+		"{ext}", ext,
+	)
+
+	rendered := replacer.Replace(n.template)
+	rendered = strings.ReplaceAll(rendered, string(filepath.Separator), "_")
+	if !strings.HasSuffix(rendered, "."+ext) {
+		rendered += "." + ext
+	}
+	return rendered
+}
+
+func isTemplatedArtifact(ext string) bool {
+	for _, templatedExt := range templatedArtifactExts {
+		if ext == templatedExt {
+			return true
+		}
+	}
+	return false
+}
+
+// abiFromFileName returns the ABI of an ABI split apk (for example app-arm64-v8a-release.apk), or universal
+func abiFromFileName(fileName string) string {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	isSeparator := func(c byte) bool {
+		return c == '-' || c == '_'
+	}
+
+	for _, abi := range androidABIs {
+		for start := 0; start < len(name); {
+			i := strings.Index(name[start:], abi)
+			if i < 0 {
+				break
+			}
+			i += start
+			end := i + len(abi)
+			if (i == 0 || isSeparator(name[i-1])) && (end == len(name) || isSeparator(name[end])) {
+				return abi
+			}
+			start = i + 1
+		}
+	}
+	return universalABI
+}
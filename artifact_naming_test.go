@@ -0,0 +1,120 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/cordova"
+)
+
+func Test_artifactNamer_name(t *testing.T) {
+	config := cordova.Config{
+		ID:                 "io.bitrise.app",
+		Version:            "1.2.3",
+		AndroidVersionCode: "42",
+		IOSBundleID:        "io.bitrise.ios",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		outputs  []string
+		platform string
+		want     []string
+	}{
+		{
+			name:     "No template",
+			template: "",
+			outputs:  []string{"/apk/release/app-release.apk"},
+			platform: "android",
+			want:     []string{"app-release.apk"},
+		},
+		{
+			name:     "No template, colliding flavor outputs",
+			template: "",
+			outputs:  []string{"/apk/free/release/app-release.apk", "/apk/paid/release/app-release.apk", "/apk/pro/release/app-release.apk"},
+			platform: "android",
+			want:     []string{"app-release.apk", "app-release-2.apk", "app-release-3.apk"},
+		},
+		{
+			name:     "Android template with ABI splits",
+			template: "{app_id}-{version}-{build_number}-{configuration}-{abi}.{ext}",
+			outputs:  []string{"/apk/release/app-arm64-v8a-release.apk", "/apk/release/app-x86_64-release.apk", "/apk/release/app-x86-release.apk", "/apk/release/app-universal-release.apk"},
+			platform: "android",
+			want: []string{
+				"io.bitrise.app-1.2.3-42-release-arm64-v8a.apk",
+				"io.bitrise.app-1.2.3-42-release-x86_64.apk",
+				"io.bitrise.app-1.2.3-42-release-x86.apk",
+				"io.bitrise.app-1.2.3-42-release-universal.apk",
+			},
+		},
+		{
+			name:     "iOS template without extension",
+			template: "{platform}-{app_id}-{build_number}",
+			outputs:  []string{"/build/App.ipa"},
+			platform: "ios",
+			want:     []string{"ios-io.bitrise.ios-1.2.3.ipa"},
+		},
+		{
+			name:     "Template does not apply to dSYMs",
+			template: "{app_id}.{ext}",
+			outputs:  []string{"/build/App.app.dSYM"},
+			platform: "ios",
+			want:     []string{"App.app.dSYM"},
+		},
+		{
+			name:     "Templated names collide",
+			template: "{app_id}.{ext}",
+			outputs:  []string{"/apk/free/release/app-release.apk", "/apk/paid/release/app-release.apk"},
+			platform: "android",
+			want:     []string{"io.bitrise.app.apk", "io.bitrise.app-2.apk"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namer, err := newArtifactNamer(tt.template, config, "release", t.TempDir())
+			if err != nil {
+				t.Fatalf("newArtifactNamer() error = %v", err)
+			}
+
+			for i, output := range tt.outputs {
+				if got := namer.name(output, tt.platform); got != tt.want[i] {
+					t.Errorf("name(%s) = %v, want %v", output, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_newArtifactNamer_unknownPlaceholder(t *testing.T) {
+	if _, err := newArtifactNamer("{app_id}-{flavor}.{ext}", cordova.Config{}, "release", t.TempDir()); err == nil {
+		t.Errorf("newArtifactNamer() expected error for unknown placeholder")
+	}
+}
+
+func Test_artifactNamer_name_existingDeployDirFiles(t *testing.T) {
+	deployDir := t.TempDir()
+	// exported by an earlier (debug) build
+	writeTestFile(t, filepath.Join(deployDir, "io.bitrise.app.apk"), "debug apk")
+	writeTestFile(t, filepath.Join(deployDir, "io.bitrise.app-2.apk"), "debug apk")
+
+	namer, err := newArtifactNamer("{app_id}.{ext}", cordova.Config{ID: "io.bitrise.app"}, "release", deployDir)
+	if err != nil {
+		t.Fatalf("newArtifactNamer() error = %v", err)
+	}
+
+	if got, want := namer.name("/apk/release/app-release.apk", "android"), "io.bitrise.app-3.apk"; got != want {
+		t.Errorf("name() = %v, want %v", got, want)
+	}
+}
+
+func Test_newArtifactNamer_missingDeployDir(t *testing.T) {
+	namer, err := newArtifactNamer("", cordova.Config{}, "release", filepath.Join(t.TempDir(), "deploy"))
+	if err != nil {
+		t.Fatalf("newArtifactNamer() error = %v", err)
+	}
+	if got, want := namer.name("/apk/release/app-release.apk", "android"), "app-release.apk"; got != want {
+		t.Errorf("name() = %v, want %v", got, want)
+	}
+}
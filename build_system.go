@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/semver"
+)
+
+// Xcode build system input values
+const (
+	buildSystemAuto   = "auto"
+	buildSystemLegacy = "legacy"
+	buildSystemModern = "modern"
+)
+
+const (
+	legacyBuildSystemFlag = "--buildFlag='-UseModernBuildSystem=0'"
+	modernBuildSystemFlag = "--buildFlag='-UseModernBuildSystem=1'"
+)
+
+var (
+	// the new Xcode build system is supported since cordova-ios@5.0.0 (https://github.com/apache/cordova-ios/issues/407)
+	modernBuildSystemMinCordovaIOSVersion = semver.MustParse("5.0.0")
+	// cordova-ios@6 targets Xcode versions, where the legacy build system is deprecated or removed
+	legacyBuildSystemMaxCordovaIOSMajor = 5
+)
+
+// buildSystemOption returns the cordova compile option selecting the Xcode build system,
+// or an empty string if the Xcode default should be used.
+// cordovaIOSVersion is nil if the project's cordova-ios version is unknown.
+func buildSystemOption(buildSystem string, cordovaIOSVersion *semver.Version) (string, error) {
+	switch buildSystem {
+	case buildSystemLegacy:
+		if cordovaIOSVersion != nil && cordovaIOSVersion.Major > legacyBuildSystemMaxCordovaIOSMajor {
+			return "", fmt.Errorf("the legacy Xcode build system is not supported by cordova-ios %s, set the build_system input to auto or modern", cordovaIOSVersion)
+		}
+		return legacyBuildSystemFlag, nil
+	case buildSystemModern:
+		return modernBuildSystemFlag, nil
+	case buildSystemAuto:
+		if cordovaIOSVersion != nil && cordovaIOSVersion.LessThan(modernBuildSystemMinCordovaIOSVersion) {
+			return legacyBuildSystemFlag, nil
+		}
+		// The modern build system is the Xcode default since Xcode 10, the flag is redundant
+		return "", nil
+	default:
+		return "", fmt.Errorf("unknown build system: %s", buildSystem)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/semver"
+)
+
+func Test_buildSystemOption(t *testing.T) {
+	version := func(s string) *semver.Version {
+		v := semver.MustParse(s)
+		return &v
+	}
+
+	tests := []struct {
+		name              string
+		buildSystem       string
+		cordovaIOSVersion *semver.Version
+		want              string
+		wantErr           bool
+	}{
+		{"auto, unknown cordova-ios", buildSystemAuto, nil, "", false},
+		{"auto, cordova-ios 4", buildSystemAuto, version("4.5.5"), legacyBuildSystemFlag, false},
+		{"auto, cordova-ios 5", buildSystemAuto, version("5.0.0"), "", false},
+		{"auto, cordova-ios 7", buildSystemAuto, version("7.1.0"), "", false},
+		{"legacy, unknown cordova-ios", buildSystemLegacy, nil, legacyBuildSystemFlag, false},
+		{"legacy, cordova-ios 5", buildSystemLegacy, version("5.1.1"), legacyBuildSystemFlag, false},
+		{"legacy, cordova-ios 6", buildSystemLegacy, version("6.0.0"), "", true},
+		{"modern, cordova-ios 7", buildSystemModern, version("7.0.0"), modernBuildSystemFlag, false},
+		{"unknown build system", "new", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildSystemOption(tt.buildSystem, tt.cordovaIOSVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildSystemOption() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("buildSystemOption() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const universalAPKEntryName = "universal.apk"
+
+// bundletoolSigning holds the keystore used to sign the APKs generated by bundletool.
+// If KeystorePth is empty, bundletool signs with the debug keystore (~/.android/debug.keystore), if it exists.
+type bundletoolSigning struct {
+	KeystorePth      string
+	KeystorePassword string
+	KeyAlias         string
+	KeyPassword      string
+}
+
+// buildUniversalAPKArgs returns the arguments of the bundletool command, generating an APK set
+// containing a single universal APK from the aab
+func buildUniversalAPKArgs(bundletoolPth, aabPth, apksPth string, signing bundletoolSigning) []string {
+	args := []string{
+		"java", "-jar", bundletoolPth, "build-apks",
+		"--bundle=" + aabPth,
+		"--output=" + apksPth,
+		"--mode=universal",
+		"--overwrite",
+	}
+
+	if signing.KeystorePth != "" {
+		args = append(args, "--ks="+signing.KeystorePth)
+		if signing.KeystorePassword != "" {
+			args = append(args, "--ks-pass=pass:"+signing.KeystorePassword)
+		}
+		if signing.KeyAlias != "" {
+			args = append(args, "--ks-key-alias="+signing.KeyAlias)
+		}
+		if signing.KeyPassword != "" {
+			args = append(args, "--key-pass=pass:"+signing.KeyPassword)
+		}
+	}
+	return args
+}
+
+// printableBundletoolArgs masks the passwords of the bundletool command
+func printableBundletoolArgs(args []string) string {
+	var printable []string
+	for _, arg := range args {
+		for _, flag := range []string{"--ks-pass=pass:", "--key-pass=pass:"} {
+			if strings.HasPrefix(arg, flag) {
+				arg = flag + "***"
+			}
+		}
+		printable = append(printable, arg)
+	}
+	return command.PrintableCommandArgs(false, printable)
+}
+
+// generateUniversalAPK runs bundletool locally to create the universal APK of the aab at apkPth
+func generateUniversalAPK(bundletoolPth, aabPth, apkPth string, signing bundletoolSigning) error {
+	tmpDir, err := os.MkdirTemp("", "bundletool")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Warnf("Failed to remove %s: %s", tmpDir, err)
+		}
+	}()
+
+	apksPth := filepath.Join(tmpDir, strings.TrimSuffix(filepath.Base(aabPth), filepath.Ext(aabPth))+".apks")
+	args := buildUniversalAPKArgs(bundletoolPth, aabPth, apksPth, signing)
+	cmd, err := command.NewFromSlice(args)
+	if err != nil {
+		return err
+	}
+
+	log.Donef("$ %s", printableBundletoolArgs(args))
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("bundletool build-apks failed, output: %s, error: %s", out, err)
+	}
+
+	return extractUniversalAPK(apksPth, apkPth)
+}
+
+// extractUniversalAPK copies the universal APK out of an APK set (.apks)
+func extractUniversalAPK(apksPth, apkPth string) error {
+	zipReader, err := zip.OpenReader(apksPth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := zipReader.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", apksPth, err)
+		}
+	}()
+
+	for _, file := range zipReader.File {
+		if filepath.Base(file.Name) != universalAPKEntryName {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := rc.Close(); err != nil {
+				log.Warnf("Failed to close %s: %s", file.Name, err)
+			}
+		}()
+
+		apk, err := os.Create(apkPth)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(apk, rc); err != nil {
+			if closeErr := apk.Close(); closeErr != nil {
+				log.Warnf("Failed to close %s: %s", apkPth, closeErr)
+			}
+			return err
+		}
+		return apk.Close()
+	}
+
+	return fmt.Errorf("no %s found in %s", universalAPKEntryName, apksPth)
+}
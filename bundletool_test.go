@@ -0,0 +1,84 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_buildUniversalAPKArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		signing bundletoolSigning
+		want    []string
+	}{
+		{
+			name:    "Debug keystore",
+			signing: bundletoolSigning{},
+			want:    []string{"java", "-jar", "/tools/bundletool.jar", "build-apks", "--bundle=/deploy/app.aab", "--output=/tmp/app.apks", "--mode=universal", "--overwrite"},
+		},
+		{
+			name:    "Keystore",
+			signing: bundletoolSigning{KeystorePth: "/keys/release.jks", KeystorePassword: "ks-secret", KeyAlias: "release", KeyPassword: "key-secret"},
+			want: []string{"java", "-jar", "/tools/bundletool.jar", "build-apks", "--bundle=/deploy/app.aab", "--output=/tmp/app.apks", "--mode=universal", "--overwrite",
+				"--ks=/keys/release.jks", "--ks-pass=pass:ks-secret", "--ks-key-alias=release", "--key-pass=pass:key-secret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildUniversalAPKArgs("/tools/bundletool.jar", "/deploy/app.aab", "/tmp/app.apks", tt.signing)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildUniversalAPKArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_printableBundletoolArgs(t *testing.T) {
+	args := buildUniversalAPKArgs("bundletool.jar", "app.aab", "app.apks", bundletoolSigning{KeystorePth: "release.jks", KeystorePassword: "ks-secret", KeyPassword: "key-secret"})
+	want := `java "-jar" "bundletool.jar" "build-apks" "--bundle=app.aab" "--output=app.apks" "--mode=universal" "--overwrite" "--ks=release.jks" "--ks-pass=pass:***" "--key-pass=pass:***"`
+	if got := printableBundletoolArgs(args); got != want {
+		t.Errorf("printableBundletoolArgs() = %s, want %s", got, want)
+	}
+}
+
+func Test_extractUniversalAPK(t *testing.T) {
+	tmpDir := t.TempDir()
+	apksPth := filepath.Join(tmpDir, "app.apks")
+
+	f, err := os.Create(apksPth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipWriter := zip.NewWriter(f)
+	for name, content := range map[string]string{"toc.pb": "toc", "universal.apk": "universal apk"} {
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	apkPth := filepath.Join(tmpDir, "app-universal.apk")
+	if err := extractUniversalAPK(apksPth, apkPth); err != nil {
+		t.Fatalf("extractUniversalAPK() error = %v", err)
+	}
+
+	content, err := os.ReadFile(apkPth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "universal apk" {
+		t.Errorf("extracted apk content = %s", content)
+	}
+}
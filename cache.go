@@ -1,32 +1,362 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bitrise-io/go-steputils/cache"
+	"github.com/bitrise-io/go-utils/log"
 	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/sliceutil"
 )
 
-// cacheNpm marks node_modules for caching
+// cacheKeyFileName is the file storing the key of a cached dir, it is cached together with the dir
+const cacheKeyFileName = ".bitrise-cache-key"
+
+// jsLockfileNames lists the lockfiles of the supported js package managers
+var jsLockfileNames = []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb", "bun.lock"}
+
+// keyedCache is a group of paths cached together, keyed on the checksum of the files they are derived from
+type keyedCache struct {
+	name string
+	// keyDir stores the key file, it needs to be one of the included dirs
+	keyDir  string
+	include []string
+	exclude []string
+	key     string
+}
+
+// cacheKey returns the checksum of the existing files' path and content, or an empty string if none of them exist
+func cacheKey(pths ...string) (string, error) {
+	hash := sha256.New()
+	found := false
+	for _, pth := range pths {
+		f, err := os.Open(pth)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		found = true
+		if _, err := io.WriteString(hash, filepath.Base(pth)+"\n"); err != nil {
+			return "", err
+		}
+		_, err = io.Copy(hash, f)
+		if closeErr := f.Close(); closeErr != nil {
+			log.Warnf("Failed to close %s: %s", pth, closeErr)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if !found {
+		return "", nil
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// storedCacheKey returns the key stored in the dir, for example by a previous build which cached the dir
+func storedCacheKey(dir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, cacheKeyFileName))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// isRestored returns true if the key dir holds the current key, meaning it was restored from an up to date cache
+func (c keyedCache) isRestored() (bool, error) {
+	if c.key == "" {
+		return false, nil
+	}
+
+	stored, err := storedCacheKey(c.keyDir)
+	if err != nil {
+		return false, err
+	}
+	return stored == c.key, nil
+}
+
+// commit marks the paths for caching, using the key file as the update indicator.
+// The paths are marked even if the restored cache matches the key: unmarked paths would be removed from the next cache,
+// while an unchanged indicator already makes the cache push skip the paths.
+func (c keyedCache) commit() error {
+	items := cache.New()
+	if c.key == "" {
+		log.Warnf("%s: no cache key available, caching without update indicator", c.name)
+		items.IncludePath(c.include...)
+	} else {
+		keyPth := filepath.Join(c.keyDir, cacheKeyFileName)
+		if err := os.WriteFile(keyPth, []byte(c.key+"\n"), 0600); err != nil {
+			return fmt.Errorf("failed to write cache key: %s", err)
+		}
+
+		for _, pth := range c.include {
+			items.IncludePath(fmt.Sprintf("%s -> %s", pth, keyPth))
+		}
+	}
+	items.ExcludePath(c.exclude...)
+
+	if err := items.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("%s: marked for caching: %s", c.name, strings.Join(c.include, ", "))
+	return nil
+}
+
+// findLockfile returns the path of the js package manager lockfile in the dir, if any
+func findLockfile(dir string) (string, error) {
+	for _, name := range jsLockfileNames {
+		pth := filepath.Join(dir, name)
+		if exist, err := pathutil.IsPathExists(pth); err != nil {
+			return "", err
+		} else if exist {
+			return pth, nil
+		}
+	}
+	return "", nil
+}
+
+// projectLockfile returns the lockfile of the project, or of its workspace root if the project is a workspace member
+func projectLockfile(workdir string) (string, error) {
+	workspace, err := findJSWorkspace(workdir)
+	if err != nil {
+		return "", err
+	}
+	if workspace != nil {
+		return findLockfile(workspace.root)
+	}
+	return findLockfile(workdir)
+}
+
+// jsDependencyCache returns the js dependency cache of the project, keyed on the lockfile:
+// the node_modules dirs, the Yarn 2+ package cache and unplugged packages, the pnpm store and the bun install cache.
+// In a workspace, the hoisted node_modules of the workspace root and the existing node_modules of the members are cached,
+// keyed on the root's lockfile.
+func jsDependencyCache(workdir string) (keyedCache, error) {
+	root := workdir
+	var packages []string
+
+	workspace, err := findJSWorkspace(workdir)
+	if err != nil {
+		return keyedCache{}, fmt.Errorf("failed to look for the js workspace: %s", err)
+	}
+	if workspace != nil {
+		root = workspace.root
+		packages = workspace.packages
+	}
+
+	packageManager, err := detectPackageManager(root)
+	if err != nil {
+		log.Warnf("%s", err)
+	}
+
+	var candidates []string
+	if packageManager.yarnBerry {
+		berryDirs, err := yarnBerryCacheDirs(root, packageManager.yarnMajorVersion)
+		if err != nil {
+			return keyedCache{}, fmt.Errorf("failed to read the Yarn settings: %s", err)
+		}
+		candidates = append(candidates, berryDirs...)
+	}
+	for _, dir := range append([]string{root}, packages...) {
+		candidates = append(candidates, filepath.Join(dir, "node_modules"))
+	}
+	switch packageManager.tool {
+	case pnpm:
+		candidates = append(candidates, pnpmStoreDir(root))
+	case bun:
+		candidates = append(candidates, bunCacheDir())
+	}
+
+	var dirs []string
+	for _, dir := range candidates {
+		if exist, err := pathutil.IsDirExists(dir); err != nil {
+			return keyedCache{}, err
+		} else if exist {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	lockfile, err := findLockfile(root)
+	if err != nil {
+		return keyedCache{}, err
+	}
+
+	key := ""
+	if lockfile != "" {
+		if key, err = cacheKey(lockfile); err != nil {
+			return keyedCache{}, fmt.Errorf("failed to checksum %s: %s", lockfile, err)
+		}
+	}
+
+	// the key is stored in the installed dependencies if they exist, so a restored key means restored node_modules,
+	// not only a restored package cache
+	keyDir := filepath.Join(root, "node_modules")
+	if !sliceutil.IsStringInSlice(keyDir, dirs) && len(dirs) > 0 {
+		keyDir = dirs[0]
+	}
+
+	return keyedCache{
+		name:    fmt.Sprintf("%s dependencies", packageManager),
+		keyDir:  keyDir,
+		include: dirs,
+		key:     key,
+	}, nil
+}
+
+// cacheNpm marks the js dependencies for caching, keyed on the lockfile
 func cacheNpm(workdir string) error {
-	localPackageDir := filepath.Join(workdir, "node_modules")
+	npmCache, err := jsDependencyCache(workdir)
+	if err != nil {
+		return err
+	}
+
+	if len(npmCache.include) == 0 {
+		return fmt.Errorf("local node_modules directory does not exist: %s", npmCache.keyDir)
+	}
+
+	if err := npmCache.commit(); err != nil {
+		return fmt.Errorf("failed to mark js dependency directories to be cached, error: %s", err)
+	}
+
+	return nil
+}
+
+// gradleCache returns the Gradle dependency and wrapper cache,
+// keyed on the Android platform's Gradle wrapper properties and build.gradle files
+func gradleCache(workdir, gradleHome string) (keyedCache, error) {
+	androidDir := filepath.Join(workdir, "platforms", "android")
+	key, err := cacheKey(
+		filepath.Join(androidDir, "gradle", "wrapper", "gradle-wrapper.properties"),
+		filepath.Join(androidDir, "build.gradle"),
+		filepath.Join(androidDir, "app", "build.gradle"),
+	)
+	if err != nil {
+		return keyedCache{}, fmt.Errorf("failed to checksum the Gradle build files: %s", err)
+	}
+
+	cachesDir := filepath.Join(gradleHome, "caches")
+	return keyedCache{
+		name:    "Gradle",
+		keyDir:  cachesDir,
+		include: []string{cachesDir, filepath.Join(gradleHome, "wrapper")},
+		exclude: []string{
+			filepath.Join(gradleHome, "**", "*.lock"),
+			filepath.Join(gradleHome, "**", "*.bin"),
+			filepath.Join(cachesDir, "journal-*"),
+		},
+		key: key,
+	}, nil
+}
 
-	switch exist, err := pathutil.IsDirExists(localPackageDir); {
+// cacheGradle marks the Gradle caches for caching, keyed on the Gradle build files
+func cacheGradle(workdir string) error {
+	gradleHome := filepath.Join(pathutil.UserHomeDir(), ".gradle")
+
+	switch exist, err := pathutil.IsDirExists(filepath.Join(gradleHome, "caches")); {
 	case err != nil:
 		return fmt.Errorf("failed to check directory existence, error: %s", err)
 	case !exist:
-		return fmt.Errorf("local node_modules directory does not exist: %s", localPackageDir)
+		return fmt.Errorf("Gradle caches directory does not exist: %s", filepath.Join(gradleHome, "caches"))
+	}
+
+	gradle, err := gradleCache(workdir, gradleHome)
+	if err != nil {
+		return err
 	}
 
-	npmCache := cache.New()
+	if err := gradle.commit(); err != nil {
+		return fmt.Errorf("failed to mark Gradle directories to be cached, error: %s", err)
+	}
 
-	// cache update indicator (package-lock.json)
-	// is not used at the moment as it cache-push performed slower with it
-	npmCache.IncludePath(localPackageDir)
+	return nil
+}
+
+// podsCache returns the CocoaPods cache of the iOS platform, keyed on the Podfile.lock
+func podsCache(workdir, cocoapodsCacheDir string) (keyedCache, error) {
+	iosDir := filepath.Join(workdir, "platforms", "ios")
+	key, err := cacheKey(filepath.Join(iosDir, "Podfile.lock"))
+	if err != nil {
+		return keyedCache{}, fmt.Errorf("failed to checksum Podfile.lock: %s", err)
+	}
+
+	podsDir := filepath.Join(iosDir, "Pods")
+	return keyedCache{
+		name:    "CocoaPods",
+		keyDir:  podsDir,
+		include: []string{podsDir, cocoapodsCacheDir},
+		key:     key,
+	}, nil
+}
+
+// podsMatchLockfile returns true if the Pods dir was installed from the Podfile.lock,
+// CocoaPods stores a copy of the lockfile in Pods/Manifest.lock
+func podsMatchLockfile(iosDir string) (bool, error) {
+	lockfile, err := os.ReadFile(filepath.Join(iosDir, "Podfile.lock"))
+	if err != nil {
+		return false, err
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(iosDir, "Pods", "Manifest.lock"))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return string(lockfile) == string(manifest), nil
+}
+
+// logRestoredPods logs whether the Pods dir restored from the cache matches the Podfile.lock
+func logRestoredPods(workdir string) {
+	iosDir := filepath.Join(workdir, "platforms", "ios")
+	if exist, err := pathutil.IsDirExists(filepath.Join(iosDir, "Pods")); err != nil || !exist {
+		log.Printf("CocoaPods: no Pods directory restored")
+		return
+	}
+	if exist, err := pathutil.IsPathExists(filepath.Join(iosDir, "Podfile.lock")); err != nil || !exist {
+		log.Printf("CocoaPods: no Podfile.lock found to compare the restored Pods directory with")
+		return
+	}
+
+	match, err := podsMatchLockfile(iosDir)
+	if err != nil {
+		log.Warnf("CocoaPods: failed to compare the restored Pods directory with Podfile.lock: %s", err)
+	} else if match {
+		log.Printf("CocoaPods: the restored Pods directory matches Podfile.lock")
+	} else {
+		log.Printf("CocoaPods: the restored Pods directory does not match Podfile.lock, pods will be reinstalled")
+	}
+}
+
+// cachePods marks the Pods dir and the CocoaPods download cache for caching, keyed on the Podfile.lock
+func cachePods(workdir string) error {
+	podsDir := filepath.Join(workdir, "platforms", "ios", "Pods")
+
+	switch exist, err := pathutil.IsDirExists(podsDir); {
+	case err != nil:
+		return fmt.Errorf("failed to check directory existence, error: %s", err)
+	case !exist:
+		return fmt.Errorf("Pods directory does not exist: %s", podsDir)
+	}
+
+	pods, err := podsCache(workdir, filepath.Join(pathutil.UserHomeDir(), "Library", "Caches", "CocoaPods"))
+	if err != nil {
+		return err
+	}
 
-	if err := npmCache.Commit(); err != nil {
-		return fmt.Errorf("failed to mark node_modules directory to be cached, error: %s", err)
+	if err := pods.commit(); err != nil {
+		return fmt.Errorf("failed to mark CocoaPods directories to be cached, error: %s", err)
 	}
 
 	return nil
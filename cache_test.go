@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_cacheKey(t *testing.T) {
+	dir := t.TempDir()
+	lockfile := filepath.Join(dir, "package-lock.json")
+	writeTestFile(t, lockfile, "lock v1")
+
+	key, err := cacheKey(lockfile, filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("cacheKey() error = %v", err)
+	}
+	if key == "" {
+		t.Fatalf("cacheKey() = empty, want a key")
+	}
+
+	if missingKey, err := cacheKey(filepath.Join(dir, "missing.json")); err != nil || missingKey != "" {
+		t.Errorf("cacheKey() of missing files = %s, %v, want empty key", missingKey, err)
+	}
+
+	writeTestFile(t, lockfile, "lock v2")
+	changedKey, err := cacheKey(lockfile)
+	if err != nil {
+		t.Fatalf("cacheKey() error = %v", err)
+	}
+	if changedKey == key {
+		t.Errorf("cacheKey() did not change with the lockfile content")
+	}
+}
+
+func Test_findLockfile(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{name: "no lockfile", files: []string{"package.json"}, want: ""},
+		{name: "npm", files: []string{"package.json", "package-lock.json"}, want: "package-lock.json"},
+		{name: "yarn", files: []string{"package.json", "yarn.lock"}, want: "yarn.lock"},
+		{name: "pnpm", files: []string{"package.json", "pnpm-lock.yaml"}, want: "pnpm-lock.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				writeTestFile(t, filepath.Join(dir, file), "{}")
+			}
+
+			got, err := findLockfile(dir)
+			if err != nil {
+				t.Fatalf("findLockfile() error = %v", err)
+			}
+			if tt.want != "" {
+				tt.want = filepath.Join(dir, tt.want)
+			}
+			if got != tt.want {
+				t.Errorf("findLockfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_keyedCache_isRestored(t *testing.T) {
+	dir := t.TempDir()
+	nodeModules := filepath.Join(dir, "node_modules")
+	writeTestFile(t, filepath.Join(dir, "yarn.lock"), "lock")
+	if err := os.MkdirAll(nodeModules, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	npmCache, err := jsDependencyCache(dir)
+	if err != nil {
+		t.Fatalf("jsDependencyCache() error = %v", err)
+	}
+
+	if restored, err := npmCache.isRestored(); err != nil || restored {
+		t.Errorf("isRestored() without stored key = %v, %v, want false", restored, err)
+	}
+
+	writeTestFile(t, filepath.Join(nodeModules, cacheKeyFileName), "outdated\n")
+	if restored, err := npmCache.isRestored(); err != nil || restored {
+		t.Errorf("isRestored() with outdated key = %v, %v, want false", restored, err)
+	}
+
+	writeTestFile(t, filepath.Join(nodeModules, cacheKeyFileName), npmCache.key+"\n")
+	if restored, err := npmCache.isRestored(); err != nil || !restored {
+		t.Errorf("isRestored() with matching key = %v, %v, want true", restored, err)
+	}
+}
+
+func Test_gradleCache(t *testing.T) {
+	workDir := t.TempDir()
+	gradleHome := filepath.Join(t.TempDir(), ".gradle")
+	buildGradle := filepath.Join(workDir, "platforms", "android", "build.gradle")
+	writeTestFile(t, filepath.Join(workDir, "platforms", "android", "gradle", "wrapper", "gradle-wrapper.properties"), "distributionUrl=gradle-7.6-all.zip")
+	writeTestFile(t, buildGradle, "buildscript {}")
+
+	gradle, err := gradleCache(workDir, gradleHome)
+	if err != nil {
+		t.Fatalf("gradleCache() error = %v", err)
+	}
+	if gradle.key == "" {
+		t.Fatalf("gradleCache() key is empty")
+	}
+	if want := filepath.Join(gradleHome, "caches"); gradle.keyDir != want {
+		t.Errorf("gradleCache() keyDir = %v, want %v", gradle.keyDir, want)
+	}
+	if len(gradle.include) != 2 || len(gradle.exclude) != 3 {
+		t.Errorf("gradleCache() include = %v, exclude = %v", gradle.include, gradle.exclude)
+	}
+
+	writeTestFile(t, buildGradle, "buildscript { dependencies {} }")
+	changed, err := gradleCache(workDir, gradleHome)
+	if err != nil {
+		t.Fatalf("gradleCache() error = %v", err)
+	}
+	if changed.key == gradle.key {
+		t.Errorf("gradleCache() key did not change with build.gradle")
+	}
+}
+
+func Test_podsMatchLockfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     bool
+	}{
+		{name: "no manifest", manifest: "", want: false},
+		{name: "matching manifest", manifest: "PODS:\n  - Firebase (10.0.0)\n", want: true},
+		{name: "outdated manifest", manifest: "PODS:\n  - Firebase (9.0.0)\n", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iosDir := t.TempDir()
+			writeTestFile(t, filepath.Join(iosDir, "Podfile.lock"), "PODS:\n  - Firebase (10.0.0)\n")
+			if tt.manifest != "" {
+				writeTestFile(t, filepath.Join(iosDir, "Pods", "Manifest.lock"), tt.manifest)
+			}
+
+			got, err := podsMatchLockfile(iosDir)
+			if err != nil {
+				t.Fatalf("podsMatchLockfile() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("podsMatchLockfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
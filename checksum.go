@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/tools"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// Checksum algorithms
+const (
+	sha256Checksum = "sha256"
+	sha512Checksum = "sha512"
+)
+
+// parseChecksumAlgorithms parses the checksum_sidecars input: a comma separated list of algorithms, or none
+func parseChecksumAlgorithms(s string) ([]string, error) {
+	if s == "" || s == "none" {
+		return nil, nil
+	}
+
+	var algorithms []string
+	for _, algorithm := range strings.Split(s, ",") {
+		algorithm = strings.TrimSpace(algorithm)
+		if algorithm != sha256Checksum && algorithm != sha512Checksum {
+			return nil, fmt.Errorf("unknown checksum algorithm: %s", algorithm)
+		}
+		algorithms = append(algorithms, algorithm)
+	}
+	return algorithms, nil
+}
+
+// fileDigests computes the hex encoded digests of the file with every given algorithm, reading the file once
+func fileDigests(pth string, algorithms ...string) (map[string]string, error) {
+	hashes := map[string]hash.Hash{}
+	var writers []io.Writer
+	for _, algorithm := range algorithms {
+		if _, ok := hashes[algorithm]; ok {
+			continue
+		}
+
+		var h hash.Hash
+		switch algorithm {
+		case sha256Checksum:
+			h = sha256.New()
+		case sha512Checksum:
+			h = sha512.New()
+		default:
+			return nil, fmt.Errorf("unknown checksum algorithm: %s", algorithm)
+		}
+		hashes[algorithm] = h
+		writers = append(writers, h)
+	}
+
+	f, err := os.Open(pth)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", pth, err)
+		}
+	}()
+
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", pth, err)
+	}
+
+	digests := map[string]string{}
+	for algorithm, h := range hashes {
+		digests[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+	return digests, nil
+}
+
+func fileSHA256(pth string) (string, error) {
+	digests, err := fileDigests(pth, sha256Checksum)
+	if err != nil {
+		return "", err
+	}
+	return digests[sha256Checksum], nil
+}
+
+// writeChecksumSidecars writes a <file>.<algorithm> file next to the file for the given algorithms,
+// in the format of the sha256sum and sha512sum tools
+func writeChecksumSidecars(pth string, digests map[string]string, algorithms []string) error {
+	for _, algorithm := range algorithms {
+		content := fmt.Sprintf("%s  %s\n", digests[algorithm], filepath.Base(pth))
+		if err := os.WriteFile(pth+"."+algorithm, []byte(content), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportChecksums exports the digests of the file exported in envKey, as <envKey>_SHA256 and <envKey>_SHA512
+func exportChecksums(envKey string, digests map[string]string, algorithms []string) error {
+	for _, algorithm := range algorithms {
+		checksumEnvKey := envKey + "_" + strings.ToUpper(algorithm)
+		if err := tools.ExportEnvironmentWithEnvman(checksumEnvKey, digests[algorithm]); err != nil {
+			return err
+		}
+		log.Printf("The %s checksum is now available in the Environment Variable: %s (value: %s)", algorithm, checksumEnvKey, digests[algorithm])
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_parseChecksumAlgorithms(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"", nil, false},
+		{"sha256", []string{"sha256"}, false},
+		{"sha256,sha512", []string{"sha256", "sha512"}, false},
+		{"md5", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseChecksumAlgorithms(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseChecksumAlgorithms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseChecksumAlgorithms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeChecksumSidecars(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "app-release.aab")
+	writeTestFile(t, pth, "aab")
+
+	algorithms := []string{sha256Checksum, sha512Checksum}
+	digests, err := fileDigests(pth, algorithms...)
+	if err != nil {
+		t.Fatalf("fileDigests() error = %v", err)
+	}
+
+	wantDigests := map[string]string{
+		sha256Checksum: "38760eabb666e8e61ee628a17c4090cc50728e095ff24218119d51bd22475363",
+		sha512Checksum: "84387a560c74cd17a3e1d618181bd7734cacdb1d7b5a52edf20fbb27c4fefe25bd4f839c12e842c61ccd57308fd6a6b3987dc237accd213b9818d751c3990c10",
+	}
+	if !reflect.DeepEqual(digests, wantDigests) {
+		t.Fatalf("fileDigests() = %v, want %v", digests, wantDigests)
+	}
+
+	if err := writeChecksumSidecars(pth, digests, algorithms); err != nil {
+		t.Fatalf("writeChecksumSidecars() error = %v", err)
+	}
+
+	for _, algorithm := range algorithms {
+		content, err := os.ReadFile(pth + "." + algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := digests[algorithm] + "  app-release.aab\n"; string(content) != want {
+			t.Errorf("%s sidecar = %q, want %q", algorithm, content, want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package cordova
+
+import (
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// LocalBinary returns the command running the project-local cordova CLI, if the project's package.json pins cordova:
+// node_modules/.bin/cordova if it is installed in the project, npx otherwise (which also finds the CLI hoisted to a workspace root).
+// Returns nil if the project does not depend on cordova.
+func LocalBinary(workDir string) ([]string, error) {
+	var projectPackage packageJSON
+	if found, err := readJSON(filepath.Join(workDir, "package.json"), &projectPackage); err != nil {
+		return nil, err
+	} else if !found || projectPackage.Dependency("cordova") == "" {
+		return nil, nil
+	}
+
+	binPth, err := filepath.Abs(filepath.Join(workDir, "node_modules", ".bin", "cordova"))
+	if err != nil {
+		return nil, err
+	}
+	if exist, err := pathutil.IsPathExists(binPth); err != nil {
+		return nil, err
+	} else if exist {
+		return []string{binPth}, nil
+	}
+
+	return []string{"npx", "--no-install", "cordova"}, nil
+}
@@ -0,0 +1,64 @@
+package cordova
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLocalBinary(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		installed   bool
+		want        []string
+	}{
+		{name: "no package.json", want: nil},
+		{name: "cordova not pinned", packageJSON: `{"dependencies": {"cordova-android": "^12.0.0"}}`, want: nil},
+		{name: "installed", packageJSON: `{"devDependencies": {"cordova": "^12.0.0"}}`, installed: true, want: []string{"<bin>"}},
+		{name: "not installed", packageJSON: `{"devDependencies": {"cordova": "12.0.0"}}`, want: []string{"npx", "--no-install", "cordova"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			if tt.packageJSON != "" {
+				writeFile(t, filepath.Join(workDir, "package.json"), tt.packageJSON)
+			}
+			binPth := filepath.Join(workDir, "node_modules", ".bin", "cordova")
+			if tt.installed {
+				writeFile(t, binPth, "#!/usr/bin/env node")
+			}
+
+			want := tt.want
+			if reflect.DeepEqual(want, []string{"<bin>"}) {
+				want = []string{binPth}
+			}
+
+			got, err := LocalBinary(workDir)
+			if err != nil {
+				t.Fatalf("LocalBinary() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("LocalBinary() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestModel_SetBinary(t *testing.T) {
+	cmd := New().SetBinary("npx", "--no-install", "cordova").SetPlatforms("ios").PrepareCommand()
+	if got, want := cmd.PrintableCommandArgs(), `npx "--no-install" "cordova" "prepare" "ios"`; got != want {
+		t.Errorf("PrepareCommand() = %v, want %v", got, want)
+	}
+}
+
+func writeFile(t *testing.T, pth, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(pth), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pth, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,68 @@
+package cordova
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/semver"
+)
+
+// Config holds the app identity attributes of the project's config.xml widget element
+type Config struct {
+	ID                 string `xml:"id,attr"`
+	Version            string `xml:"version,attr"`
+	AndroidPackageName string `xml:"android-packageName,attr"`
+	AndroidVersionCode string `xml:"android-versionCode,attr"`
+	IOSBundleID        string `xml:"ios-CFBundleIdentifier,attr"`
+	IOSBundleVersion   string `xml:"ios-CFBundleVersion,attr"`
+}
+
+// ParseConfig parses the given config.xml
+func ParseConfig(pth string) (Config, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	if err := xml.Unmarshal(content, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse %s: %s", pth, err)
+	}
+	return config, nil
+}
+
+// AppID returns the package name (android) or bundle identifier (ios) of the app
+func (c Config) AppID(platform string) string {
+	switch {
+	case platform == "android" && c.AndroidPackageName != "":
+		return c.AndroidPackageName
+	case platform == "ios" && c.IOSBundleID != "":
+		return c.IOSBundleID
+	}
+	return c.ID
+}
+
+// BuildNumber returns the version code (android) or bundle version (ios) of the app,
+// falling back to the value cordova generates from the version.
+func (c Config) BuildNumber(platform string) string {
+	switch platform {
+	case "android":
+		if c.AndroidVersionCode != "" {
+			return c.AndroidVersionCode
+		}
+		// cordova-android default: MAJOR * 10000 + MINOR * 100 + PATCH
+		version, err := semver.Parse(c.Version)
+		if err != nil {
+			return ""
+		}
+		return strconv.Itoa(version.Major*10000 + version.Minor*100 + version.Patch)
+	case "ios":
+		if c.IOSBundleVersion != "" {
+			return c.IOSBundleVersion
+		}
+		return c.Version
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package cordova
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "config.xml")
+	content := `<?xml version='1.0' encoding='utf-8'?>
+<widget id="io.bitrise.app" version="2.1.3" android-versionCode="210300" ios-CFBundleIdentifier="io.bitrise.ios" xmlns="http://www.w3.org/ns/widgets" xmlns:cdv="http://cordova.apache.org/ns/1.0">
+    <name>Sample</name>
+</widget>
+`
+	if err := os.WriteFile(pth, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ParseConfig(pth)
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"android app id", config.AppID("android"), "io.bitrise.app"},
+		{"ios app id", config.AppID("ios"), "io.bitrise.ios"},
+		{"android build number", config.BuildNumber("android"), "210300"},
+		{"ios build number", config.BuildNumber("ios"), "2.1.3"},
+		{"default android build number", Config{Version: "1.2.3"}.BuildNumber("android"), "10203"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %s, want %s", tt.got, tt.want)
+			}
+		})
+	}
+}
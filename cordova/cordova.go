@@ -8,6 +8,7 @@ import (
 
 // Model ...
 type Model struct {
+	binary         []string
 	platforms      []string
 	configuration  string
 	target         string
@@ -21,6 +22,13 @@ func New() *Model {
 	return &Model{}
 }
 
+// SetBinary sets the command running the cordova CLI, for example a project-local node_modules/.bin/cordova.
+// Defaults to the cordova binary found in PATH.
+func (builder *Model) SetBinary(binary ...string) *Model {
+	builder.binary = binary
+	return builder
+}
+
 // SetPlatforms ...
 func (builder *Model) SetPlatforms(platforms ...string) *Model {
 	builder.platforms = platforms
@@ -60,6 +68,9 @@ func (builder *Model) SetCustomOptions(customOptions ...string) *Model {
 
 func (builder *Model) commandSlice(cmd ...string) []string {
 	cmdSlice := []string{"cordova"}
+	if len(builder.binary) > 0 {
+		cmdSlice = append([]string{}, builder.binary...)
+	}
 	cmdSlice = append(cmdSlice, cmd...)
 
 	if len(cmd) == 1 && cmd[0] == "compile" {
@@ -0,0 +1,85 @@
+package cordova
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/semver"
+)
+
+// PlatformVersion returns the version of the given cordova platform package (for example cordova-ios) used by the project.
+// The installed platform (platforms/platforms.json) is preferred, then the version detected by PackageVersion.
+// Returns nil if the version can not be detected.
+func PlatformVersion(workDir, platform string) (*semver.Version, error) {
+	var platformVersions map[string]string
+	if found, err := readJSON(filepath.Join(workDir, "platforms", "platforms.json"), &platformVersions); err != nil {
+		return nil, err
+	} else if found {
+		if version, err := semver.Parse(platformVersions[platform]); err == nil {
+			return &version, nil
+		}
+	}
+
+	return PackageVersion(workDir, "cordova-"+platform)
+}
+
+// PackageVersion returns the version of the given npm package (for example cordova) the project depends on.
+// The installed npm package is preferred, then the lower bound of the version requirement in package.json.
+// Returns nil if the project does not depend on the package, or the version can not be detected.
+func PackageVersion(workDir, packageName string) (*semver.Version, error) {
+	var installedPackage packageJSON
+	if found, err := readJSON(filepath.Join(workDir, "node_modules", packageName, "package.json"), &installedPackage); err != nil {
+		return nil, err
+	} else if found {
+		if version, err := semver.Parse(installedPackage.Version); err == nil {
+			return &version, nil
+		}
+	}
+
+	var projectPackage packageJSON
+	if found, err := readJSON(filepath.Join(workDir, "package.json"), &projectPackage); err != nil {
+		return nil, err
+	} else if found {
+		if version, err := semver.Parse(versionRequirementLowerBound(projectPackage.Dependency(packageName))); err == nil {
+			return &version, nil
+		}
+	}
+
+	return nil, nil
+}
+
+type packageJSON struct {
+	Version         string            `json:"version"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+// Dependency returns the version requirement of the given (dev) dependency
+func (p packageJSON) Dependency(name string) string {
+	if requirement, ok := p.Dependencies[name]; ok {
+		return requirement
+	}
+	return p.DevDependencies[name]
+}
+
+// versionRequirementLowerBound returns the version in simple requirements, like "^6.2.0", "~6.2.0" or ">=6.2.0"
+func versionRequirementLowerBound(requirement string) string {
+	return strings.TrimLeft(strings.TrimSpace(requirement), "^~>=v")
+}
+
+func readJSON(pth string, v interface{}) (bool, error) {
+	content, err := os.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(content, v); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %s", pth, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,132 @@
+package cordova
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlatformVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "Installed platform",
+			files: map[string]string{
+				"platforms/platforms.json":              `{"ios":"6.2.0","android":"10.1.2"}`,
+				"node_modules/cordova-ios/package.json": `{"version":"7.0.1"}`,
+			},
+			want: "6.2.0",
+		},
+		{
+			name: "Installed npm package",
+			files: map[string]string{
+				"node_modules/cordova-ios/package.json": `{"version":"7.0.1"}`,
+				"package.json":                          `{"devDependencies":{"cordova-ios":"^6.0.0"}}`,
+			},
+			want: "7.0.1",
+		},
+		{
+			name: "package.json requirement",
+			files: map[string]string{
+				"package.json": `{"devDependencies":{"cordova-ios":"^6.1.0"}}`,
+			},
+			want: "6.1.0",
+		},
+		{
+			name: "Not parsable requirement",
+			files: map[string]string{
+				"package.json": `{"dependencies":{"cordova-ios":"github:apache/cordova-ios"}}`,
+			},
+			want: "",
+		},
+		{
+			name:  "Unknown",
+			files: map[string]string{},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			for name, content := range tt.files {
+				pth := filepath.Join(workDir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(pth), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(pth, []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := PlatformVersion(workDir, "ios")
+			if err != nil {
+				t.Fatalf("PlatformVersion() error = %v", err)
+			}
+
+			gotStr := ""
+			if got != nil {
+				gotStr = got.String()
+			}
+			if gotStr != tt.want {
+				t.Errorf("PlatformVersion() = %s, want %s", gotStr, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "Installed npm package",
+			files: map[string]string{
+				"node_modules/cordova/package.json": `{"version":"12.0.0"}`,
+				"package.json":                      `{"devDependencies":{"cordova":"^11.0.0"}}`,
+			},
+			want: "12.0.0",
+		},
+		{
+			name: "package.json requirement",
+			files: map[string]string{
+				"package.json": `{"devDependencies":{"cordova":"^12.0.0"}}`,
+			},
+			want: "12.0.0",
+		},
+		{
+			name: "Not a dependency",
+			files: map[string]string{
+				"package.json": `{"devDependencies":{"cordova-android":"^12.0.0"}}`,
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(workDir, filepath.FromSlash(name)), content)
+			}
+
+			got, err := PackageVersion(workDir, "cordova")
+			if err != nil {
+				t.Fatalf("PackageVersion() error = %v", err)
+			}
+
+			gotStr := ""
+			if got != nil {
+				gotStr = got.String()
+			}
+			if gotStr != tt.want {
+				t.Errorf("PackageVersion() = %s, want %s", gotStr, tt.want)
+			}
+		})
+	}
+}
@@ -12,10 +12,19 @@ func CurrentVersion() (string, error) {
 	return toolVersion("cordova")
 }
 
-func toolVersion(tool string) (string, error) {
-	out, err := command.New(tool, "-v").RunAndReturnTrimmedCombinedOutput()
+// BinaryVersion returns the version of the cordova CLI run by the given command
+func BinaryVersion(binary ...string) (string, error) {
+	if len(binary) == 0 {
+		return CurrentVersion()
+	}
+	return toolVersion(binary[0], binary[1:]...)
+}
+
+func toolVersion(tool string, args ...string) (string, error) {
+	cmd := command.New(tool, append(args, "-v")...)
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("$ %s -v failed, output: %s, error: %s", tool, out, err)
+		return "", fmt.Errorf("$ %s failed, output: %s, error: %s", cmd.PrintableCommandArgs(), out, err)
 	}
 
 	lines := strings.Split(out, "\n")
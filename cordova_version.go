@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-steplib/steps-cordova-archive/semver"
+)
+
+// latestVersion is the cordova_version input value requesting the latest release
+const latestVersion = "latest"
+
+// parseCordovaVersion parses the output of cordova -v, which prints the version,
+// optionally followed by the cordova-lib version: 12.0.0 (cordova-lib@12.0.1)
+func parseCordovaVersion(out string) (semver.Version, error) {
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return semver.Version{}, fmt.Errorf("invalid cordova version: %s", out)
+	}
+	return semver.Parse(fields[0])
+}
+
+// isVersionSatisfied returns true if the installed version satisfies the requested version or version range.
+// The latest version is resolved only if it is requested.
+// An unknown installed version does not satisfy any request.
+func isVersionSatisfied(installed, requested string, resolveLatest func() (string, error)) (bool, error) {
+	installedVersion, err := parseCordovaVersion(installed)
+	if err != nil {
+		return false, nil
+	}
+
+	if requested == latestVersion {
+		if requested, err = resolveLatest(); err != nil {
+			return false, err
+		}
+	}
+
+	versionRange, err := semver.ParseRange(requested)
+	if err != nil {
+		return false, err
+	}
+	return versionRange.Contains(installedVersion), nil
+}
+
+// latestPackageVersion returns the latest version of the package in the configured registry
+func latestPackageVersion(name string) (string, error) {
+	cmd := command.New("npm", "view", name+"@"+latestVersion, "version")
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("$ %s failed, output: %s, error: %s", cmd.PrintableCommandArgs(), out, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_isVersionSatisfied(t *testing.T) {
+	latest := func() (string, error) { return "12.0.0", nil }
+	tests := []struct {
+		name          string
+		installed     string
+		requested     string
+		resolveLatest func() (string, error)
+		want          bool
+		wantErr       bool
+	}{
+		{name: "exact match", installed: "12.0.0", requested: "12.0.0", want: true},
+		{name: "exact mismatch", installed: "11.1.0", requested: "12.0.0", want: false},
+		{name: "caret range", installed: "12.0.1", requested: "^12", want: true},
+		{name: "tilde range", installed: "11.2.0", requested: "~11.1", want: false},
+		{name: "latest installed", installed: "12.0.0", requested: "latest", resolveLatest: latest, want: true},
+		{name: "latest outdated", installed: "11.0.0", requested: "latest", resolveLatest: latest, want: false},
+		{name: "latest unavailable", installed: "12.0.0", requested: "latest", resolveLatest: func() (string, error) { return "", errors.New("offline") }, wantErr: true},
+		{name: "version with cordova-lib version", installed: "12.0.0 (cordova-lib@12.0.1)", requested: "^12", want: true},
+		{name: "unknown installed version", installed: "", requested: "^12", want: false},
+		{name: "dist tag", installed: "12.0.0", requested: "nightly", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolveLatest := tt.resolveLatest
+			if resolveLatest == nil {
+				resolveLatest = func() (string, error) {
+					t.Fatalf("latest version resolved unnecessarily")
+					return "", nil
+				}
+			}
+
+			got, err := isVersionSatisfied(tt.installed, tt.requested, resolveLatest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("isVersionSatisfied() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("isVersionSatisfied() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
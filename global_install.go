@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/jsdependency"
+	"github.com/bitrise-io/go-utils/errorutil"
+)
+
+// globalInstallArgs returns the command installing the package globally with the package manager.
+// Yarn 2+ has no global installs, npm is used instead.
+func globalInstallArgs(manager jsPackageManager, pkg string) []string {
+	switch {
+	case manager.tool == pnpm:
+		return []string{"pnpm", "add", "--global", pkg}
+	case manager.tool == bun:
+		return []string{"bun", "add", "--global", pkg}
+	case manager.tool == jsdependency.Yarn && !manager.yarnBerry:
+		return []string{"yarn", "global", "add", pkg}
+	}
+	return []string{"npm", "install", "--global", pkg}
+}
+
+// globalInstallError describes a failed global install, with a hint for the known failures of the package manager
+func globalInstallError(manager jsPackageManager, printableCmd, out string, err error) error {
+	exitCode, _ := errorutil.CmdExitCodeFromError(err)
+	if exitCode == -1 {
+		return fmt.Errorf("%s failed, error: %s", printableCmd, err)
+	}
+
+	var hint string
+	switch {
+	case strings.Contains(out, "E404"), strings.Contains(out, "ERR_PNPM_NO_MATCHING_VERSION"),
+		strings.Contains(out, "Couldn't find any versions"), strings.Contains(out, "No version matching"):
+		hint = "the requested version is not available in the registry"
+	case manager.tool == pnpm && strings.Contains(out, "ERR_PNPM_NO_GLOBAL_BIN_DIR"):
+		hint = "pnpm has no global bin directory, set PNPM_HOME and add it to the PATH (see pnpm setup)"
+	case strings.Contains(out, "EACCES"):
+		hint = "the global install directory is not writable"
+	}
+
+	if hint != "" {
+		return fmt.Errorf("%s failed with exit code %d (%s), output: %s", printableCmd, exitCode, hint, out)
+	}
+	return fmt.Errorf("%s failed with exit code %d, output: %s", printableCmd, exitCode, out)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-steputils/jsdependency"
+)
+
+func Test_globalInstallArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		manager jsPackageManager
+		want    []string
+	}{
+		{name: "npm", manager: jsPackageManager{tool: jsdependency.Npm}, want: []string{"npm", "install", "--global", "cordova@^12"}},
+		{name: "yarn", manager: jsPackageManager{tool: jsdependency.Yarn}, want: []string{"yarn", "global", "add", "cordova@^12"}},
+		{name: "yarn berry", manager: jsPackageManager{tool: jsdependency.Yarn, yarnBerry: true}, want: []string{"npm", "install", "--global", "cordova@^12"}},
+		{name: "pnpm", manager: jsPackageManager{tool: pnpm}, want: []string{"pnpm", "add", "--global", "cordova@^12"}},
+		{name: "bun", manager: jsPackageManager{tool: bun}, want: []string{"bun", "add", "--global", "cordova@^12"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := globalInstallArgs(tt.manager, "cordova@^12"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("globalInstallArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_globalInstallError(t *testing.T) {
+	exitErr := exec.Command("false").Run()
+	if exitErr == nil {
+		t.Fatal("expected an exit status error")
+	}
+
+	tests := []struct {
+		name    string
+		manager jsPackageManager
+		out     string
+		err     error
+		want    string
+	}{
+		{name: "command not started", manager: jsPackageManager{tool: bun}, err: errors.New(`exec: "bun": executable file not found in $PATH`), want: "executable file not found"},
+		{name: "missing version", manager: jsPackageManager{tool: jsdependency.Npm}, out: "npm ERR! code E404", err: exitErr, want: "exit code 1 (the requested version is not available in the registry)"},
+		{name: "pnpm without global bin dir", manager: jsPackageManager{tool: pnpm}, out: "ERR_PNPM_NO_GLOBAL_BIN_DIR  Unable to find the global bin directory", err: exitErr, want: "set PNPM_HOME"},
+		{name: "permission denied", manager: jsPackageManager{tool: jsdependency.Npm}, out: "npm ERR! code EACCES", err: exitErr, want: "not writable"},
+		{name: "other failure", manager: jsPackageManager{tool: jsdependency.Yarn}, out: "error An unexpected error occurred", err: exitErr, want: "exit code 1, output: error An unexpected error occurred"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := globalInstallError(tt.manager, "install", tt.out, tt.err)
+			if !strings.Contains(got.Error(), tt.want) {
+				t.Errorf("globalInstallError() = %v, want it to contain %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,572 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const iosAppMetadataFileName = "ios-app-metadata.json"
+
+// Nested bundle types listed in the iOS app inventory
+const (
+	appExtensionBundle = "app_extension"
+	watchAppBundle     = "watch_app"
+	frameworkBundle    = "framework"
+)
+
+// provisioningProfileInfo describes a provisioning profile embedded in a bundle, decoded from its embedded.mobileprovision
+type provisioningProfileInfo struct {
+	Name string `json:"name"`
+	// ApplicationID is the application-identifier entitlement: the app id prefix followed by the bundle id or a wildcard,
+	// for example ABCDE12345.io.bitrise.app or ABCDE12345.*
+	ApplicationID string `json:"application_identifier"`
+	TeamID        string `json:"team_id"`
+}
+
+// bundleInfo describes a bundle embedded in an iOS app, read from its Info.plist
+type bundleInfo struct {
+	Type                   string                   `json:"type"`
+	Path                   string                   `json:"path"`
+	BundleID               string                   `json:"bundle_id"`
+	Version                string                   `json:"version"`
+	BuildNumber            string                   `json:"build_number"`
+	HasProvisioningProfile bool                     `json:"has_provisioning_profile"`
+	ProvisioningProfile    *provisioningProfileInfo `json:"provisioning_profile,omitempty"`
+}
+
+// iosAppMetadata describes an exported .app or .ipa and the bundles embedded in it
+type iosAppMetadata struct {
+	Path                   string                   `json:"path"`
+	BundleID               string                   `json:"bundle_id"`
+	Version                string                   `json:"version"`
+	BuildNumber            string                   `json:"build_number"`
+	HasProvisioningProfile bool                     `json:"has_provisioning_profile"`
+	ProvisioningProfile    *provisioningProfileInfo `json:"provisioning_profile,omitempty"`
+	AppExtensions          []bundleInfo             `json:"app_extensions"`
+	WatchApps              []bundleInfo             `json:"watch_apps"`
+	Frameworks             []bundleInfo             `json:"frameworks"`
+}
+
+// appBundleReader gives access to the content of an .app, either extracted or inside an .ipa
+type appBundleReader interface {
+	// Files returns the slash separated paths of the bundle's files, relative to the .app root
+	Files() []string
+	ReadFile(relPth string) ([]byte, error)
+	Close() error
+}
+
+type dirAppBundleReader struct {
+	root  string
+	files []string
+}
+
+func newDirAppBundleReader(appPth string) (*dirAppBundleReader, error) {
+	reader := &dirAppBundleReader{root: appPth}
+	if err := filepath.Walk(appPth, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			log.Warnf("Failed to read %s: %s", pth, err)
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPth, err := filepath.Rel(appPth, pth)
+		if err != nil {
+			return err
+		}
+		reader.files = append(reader.files, filepath.ToSlash(relPth))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+func (r *dirAppBundleReader) Files() []string {
+	return r.files
+}
+
+func (r *dirAppBundleReader) ReadFile(relPth string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(r.root, filepath.FromSlash(relPth)))
+}
+
+func (r *dirAppBundleReader) Close() error {
+	return nil
+}
+
+type ipaAppBundleReader struct {
+	zipReader *zip.ReadCloser
+	entries   map[string]*zip.File
+	files     []string
+}
+
+func newIpaAppBundleReader(ipaPth string) (*ipaAppBundleReader, error) {
+	zipReader, err := zip.OpenReader(ipaPth)
+	if err != nil {
+		return nil, err
+	}
+
+	appRoot := ""
+	for _, file := range zipReader.File {
+		components := strings.Split(file.Name, "/")
+		if len(components) > 2 && components[0] == "Payload" && strings.HasSuffix(components[1], ".app") {
+			appRoot = components[0] + "/" + components[1] + "/"
+			break
+		}
+	}
+	if appRoot == "" {
+		if err := zipReader.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", ipaPth, err)
+		}
+		return nil, fmt.Errorf("no Payload/*.app found in %s", ipaPth)
+	}
+
+	reader := &ipaAppBundleReader{zipReader: zipReader, entries: map[string]*zip.File{}}
+	for _, file := range zipReader.File {
+		if !strings.HasPrefix(file.Name, appRoot) || file.FileInfo().IsDir() {
+			continue
+		}
+
+		relPth := strings.TrimPrefix(file.Name, appRoot)
+		reader.entries[relPth] = file
+		reader.files = append(reader.files, relPth)
+	}
+	return reader, nil
+}
+
+func (r *ipaAppBundleReader) Files() []string {
+	return r.files
+}
+
+func (r *ipaAppBundleReader) ReadFile(relPth string) ([]byte, error) {
+	file, ok := r.entries[relPth]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rc.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", relPth, err)
+		}
+	}()
+
+	return io.ReadAll(rc)
+}
+
+func (r *ipaAppBundleReader) Close() error {
+	return r.zipReader.Close()
+}
+
+// inventoryIOSArtifact opens an exported .app or .ipa and lists its embedded bundles
+func inventoryIOSArtifact(pth string) (iosAppMetadata, error) {
+	var reader appBundleReader
+	var err error
+	if filepath.Ext(pth) == ".ipa" {
+		reader, err = newIpaAppBundleReader(pth)
+	} else {
+		reader, err = newDirAppBundleReader(pth)
+	}
+	if err != nil {
+		return iosAppMetadata{}, err
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", pth, err)
+		}
+	}()
+
+	metadata, err := inventoryAppBundle(reader)
+	if err != nil {
+		return iosAppMetadata{}, err
+	}
+	metadata.Path = pth
+	return metadata, nil
+}
+
+func inventoryAppBundle(reader appBundleReader) (iosAppMetadata, error) {
+	files := map[string]bool{}
+	for _, file := range reader.Files() {
+		files[file] = true
+	}
+
+	mainBundle, err := readBundleInfo(reader, files, "")
+	if err != nil {
+		return iosAppMetadata{}, fmt.Errorf("failed to read the app's Info.plist: %s", err)
+	}
+
+	metadata := iosAppMetadata{
+		BundleID:               mainBundle.BundleID,
+		Version:                mainBundle.Version,
+		BuildNumber:            mainBundle.BuildNumber,
+		HasProvisioningProfile: mainBundle.HasProvisioningProfile,
+		ProvisioningProfile:    mainBundle.ProvisioningProfile,
+		AppExtensions:          []bundleInfo{},
+		WatchApps:              []bundleInfo{},
+		Frameworks:             []bundleInfo{},
+	}
+
+	for _, bundleDir := range embeddedBundleDirs(reader.Files()) {
+		info, err := readBundleInfo(reader, files, bundleDir)
+		if err != nil {
+			log.Warnf("Failed to read Info.plist of %s: %s", bundleDir, err)
+			continue
+		}
+
+		switch info.Type {
+		case appExtensionBundle:
+			metadata.AppExtensions = append(metadata.AppExtensions, info)
+		case watchAppBundle:
+			metadata.WatchApps = append(metadata.WatchApps, info)
+		case frameworkBundle:
+			metadata.Frameworks = append(metadata.Frameworks, info)
+		}
+	}
+
+	return metadata, nil
+}
+
+// embeddedBundleDirs returns the PlugIns/*.appex, Watch/*.app (and their extensions) and Frameworks/*.framework dirs
+func embeddedBundleDirs(files []string) []string {
+	dirSet := map[string]bool{}
+	for _, file := range files {
+		if path.Base(file) != "Info.plist" {
+			continue
+		}
+
+		dir := path.Dir(file)
+		components := strings.Split(dir, "/")
+		switch {
+		case len(components) == 2 && components[0] == "PlugIns" && path.Ext(dir) == ".appex",
+			len(components) == 2 && components[0] == "Watch" && path.Ext(dir) == ".app",
+			len(components) == 4 && components[0] == "Watch" && components[2] == "PlugIns" && path.Ext(dir) == ".appex",
+			len(components) == 2 && components[0] == "Frameworks" && path.Ext(dir) == ".framework":
+			dirSet[dir] = true
+		}
+	}
+
+	var dirs []string
+	for dir := range dirSet {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+func readBundleInfo(reader appBundleReader, files map[string]bool, bundleDir string) (bundleInfo, error) {
+	content, err := reader.ReadFile(path.Join(bundleDir, "Info.plist"))
+	if err != nil {
+		return bundleInfo{}, err
+	}
+
+	values, err := plistStringValues(content)
+	if err != nil {
+		return bundleInfo{}, err
+	}
+
+	var bundleType string
+	switch path.Ext(bundleDir) {
+	case ".appex":
+		bundleType = appExtensionBundle
+	case ".app":
+		bundleType = watchAppBundle
+	case ".framework":
+		bundleType = frameworkBundle
+	}
+
+	info := bundleInfo{
+		Type:                   bundleType,
+		Path:                   bundleDir,
+		BundleID:               values["CFBundleIdentifier"],
+		Version:                values["CFBundleShortVersionString"],
+		BuildNumber:            values["CFBundleVersion"],
+		HasProvisioningProfile: files[path.Join(bundleDir, "embedded.mobileprovision")],
+	}
+
+	if info.HasProvisioningProfile {
+		content, err := reader.ReadFile(path.Join(bundleDir, "embedded.mobileprovision"))
+		if err == nil {
+			var profile provisioningProfileInfo
+			if profile, err = parseProvisioningProfile(content); err == nil {
+				info.ProvisioningProfile = &profile
+			}
+		}
+		if err != nil {
+			log.Warnf("Failed to read the provisioning profile of %s: %s", path.Join(bundleDir, "embedded.mobileprovision"), err)
+		}
+	}
+	return info, nil
+}
+
+// parseProvisioningProfile decodes an embedded.mobileprovision.
+// The profile is a signed CMS message, its (not encrypted) content is the XML property list of the profile.
+func parseProvisioningProfile(content []byte) (provisioningProfileInfo, error) {
+	start := bytes.Index(content, []byte("<?xml"))
+	end := bytes.LastIndex(content, []byte("</plist>"))
+	if start < 0 || end < start {
+		return provisioningProfileInfo{}, errors.New("no property list found in the provisioning profile")
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content[start : end+len("</plist>")]))
+	var profile map[string]interface{}
+	for profile == nil {
+		token, err := decoder.Token()
+		if err != nil {
+			return provisioningProfileInfo{}, err
+		}
+
+		if element, ok := token.(xml.StartElement); ok && element.Name.Local == "dict" {
+			value, err := decodePlistValue(decoder, element)
+			if err != nil {
+				return provisioningProfileInfo{}, err
+			}
+			profile = value.(map[string]interface{})
+		}
+	}
+
+	entitlements, _ := profile["Entitlements"].(map[string]interface{})
+	info := provisioningProfileInfo{}
+	info.Name, _ = profile["Name"].(string)
+	info.ApplicationID, _ = entitlements["application-identifier"].(string)
+	if teamIDs, ok := profile["TeamIdentifier"].([]interface{}); ok && len(teamIDs) > 0 {
+		info.TeamID, _ = teamIDs[0].(string)
+	}
+	if info.TeamID == "" {
+		info.TeamID, _ = entitlements["com.apple.developer.team-identifier"].(string)
+	}
+	return info, nil
+}
+
+// decodePlistValue decodes the property list value of the start element:
+// a dict as map[string]interface{}, an array as []interface{}, true and false as bool and any other value as its text
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict", "array":
+		dict := map[string]interface{}{}
+		var array []interface{}
+		key := ""
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			switch element := token.(type) {
+			case xml.StartElement:
+				if start.Name.Local == "dict" && element.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &element); err != nil {
+						return nil, err
+					}
+					continue
+				}
+
+				value, err := decodePlistValue(decoder, element)
+				if err != nil {
+					return nil, err
+				}
+				if start.Name.Local == "dict" {
+					dict[key] = value
+				} else {
+					array = append(array, value)
+				}
+			case xml.EndElement:
+				if start.Name.Local == "dict" {
+					return dict, nil
+				}
+				return array, nil
+			}
+		}
+	case "true", "false":
+		return start.Name.Local == "true", decoder.Skip()
+	}
+
+	var text string
+	if err := decoder.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	return text, nil
+}
+
+// coversBundleID returns true if the profile's application identifier matches the bundle id,
+// either exactly or by a wildcard (ABCDE12345.* or ABCDE12345.io.bitrise.*)
+func (p provisioningProfileInfo) coversBundleID(bundleID string) bool {
+	split := strings.SplitN(p.ApplicationID, ".", 2)
+	if len(split) != 2 {
+		return false
+	}
+
+	pattern := split[1]
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(bundleID, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == bundleID
+}
+
+// plistStringValues returns the top level string values of an XML or binary property list
+func plistStringValues(content []byte) (map[string]string, error) {
+	if bytes.HasPrefix(content, []byte("bplist")) {
+		converted, err := command.New("plutil", "-convert", "xml1", "-o", "-", "-").SetStdin(bytes.NewReader(content)).RunAndReturnTrimmedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert binary plist: %s", err)
+		}
+		content = []byte(converted)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	values := map[string]string{}
+	depth := 0
+	key := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			depth++
+			// plist > dict > key|value
+			if depth != 3 {
+				continue
+			}
+
+			var text string
+			if err := decoder.DecodeElement(&text, &element); err != nil {
+				return nil, err
+			}
+			depth--
+
+			if element.Name.Local == "key" {
+				key = text
+			} else {
+				if element.Name.Local == "string" && key != "" {
+					values[key] = text
+				}
+				key = ""
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	if depth != 0 {
+		return nil, errors.New("unexpected end of plist")
+	}
+	return values, nil
+}
+
+// topLevelApps drops the .app dirs nested into an other collected .app (for example watch apps)
+func topLevelApps(apps []string) []string {
+	var topLevel []string
+	for _, app := range apps {
+		nested := false
+		for _, other := range apps {
+			if other != app && strings.HasPrefix(app, other+string(os.PathSeparator)) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			topLevel = append(topLevel, app)
+		}
+	}
+	return topLevel
+}
+
+// exportIOSAppMetadata inventories the exported iOS artifacts and writes the result into the deploy dir
+func exportIOSAppMetadata(artifacts []string, deployDir string, requireProvisioningProfiles bool) ([]iosAppMetadata, string, error) {
+	metadataList := []iosAppMetadata{}
+	for _, artifact := range artifacts {
+		metadata, err := inventoryIOSArtifact(artifact)
+		if err != nil {
+			log.Warnf("Failed to inventory %s: %s", artifact, err)
+			continue
+		}
+		logIOSAppMetadata(metadata, requireProvisioningProfiles)
+		metadataList = append(metadataList, metadata)
+	}
+
+	content, err := json.MarshalIndent(metadataList, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+
+	pth := filepath.Join(deployDir, iosAppMetadataFileName)
+	if err := os.WriteFile(pth, content, 0600); err != nil {
+		return nil, "", err
+	}
+	return metadataList, pth, nil
+}
+
+func logIOSAppMetadata(metadata iosAppMetadata, requireProvisioningProfiles bool) {
+	log.Printf("%s: %s %s (%s)", filepath.Base(metadata.Path), metadata.BundleID, metadata.Version, metadata.BuildNumber)
+
+	for _, info := range nestedBundles(metadata) {
+		log.Printf("- %s: %s %s (%s)", info.Path, info.BundleID, info.Version, info.BuildNumber)
+	}
+
+	for _, warning := range provisioningProfileWarnings(metadata, requireProvisioningProfiles) {
+		log.Warnf("  %s", warning)
+	}
+}
+
+func nestedBundles(metadata iosAppMetadata) []bundleInfo {
+	var nested []bundleInfo
+	nested = append(nested, metadata.AppExtensions...)
+	nested = append(nested, metadata.WatchApps...)
+	nested = append(nested, metadata.Frameworks...)
+	return nested
+}
+
+// provisioningProfileWarnings checks that the app and its extensions and watch apps are signed with a provisioning profile
+// matching their bundle id, from the team of the app's profile. Missing profiles are only reported if they are required.
+func provisioningProfileWarnings(metadata iosAppMetadata, requireProvisioningProfiles bool) []string {
+	mainBundle := bundleInfo{
+		Path:                   filepath.Base(metadata.Path),
+		BundleID:               metadata.BundleID,
+		HasProvisioningProfile: metadata.HasProvisioningProfile,
+		ProvisioningProfile:    metadata.ProvisioningProfile,
+	}
+
+	var warnings []string
+	for _, info := range append([]bundleInfo{mainBundle}, nestedBundles(metadata)...) {
+		if info.Type == frameworkBundle {
+			continue
+		}
+
+		profile := info.ProvisioningProfile
+		switch {
+		case !info.HasProvisioningProfile:
+			if requireProvisioningProfiles {
+				warnings = append(warnings, fmt.Sprintf("%s has no embedded provisioning profile", info.Path))
+			}
+		case profile == nil:
+		case !profile.coversBundleID(info.BundleID):
+			warnings = append(warnings, fmt.Sprintf("%s (%s) is signed with the provisioning profile %s, whose application identifier (%s) does not match the bundle id",
+				info.Path, info.BundleID, profile.Name, profile.ApplicationID))
+		case metadata.ProvisioningProfile != nil && profile.TeamID != metadata.ProvisioningProfile.TeamID:
+			warnings = append(warnings, fmt.Sprintf("%s is signed with the provisioning profile %s of team %s, the app's profile is of team %s",
+				info.Path, profile.Name, profile.TeamID, metadata.ProvisioningProfile.TeamID))
+		}
+	}
+	return warnings
+}
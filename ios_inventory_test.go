@@ -0,0 +1,262 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func infoPlist(bundleID, version, buildNumber string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>` + bundleID + `</string>
+	<key>NSExtension</key>
+	<dict>
+		<key>NSExtensionPointIdentifier</key>
+		<string>com.apple.usernotifications.service</string>
+	</dict>
+	<key>UIRequiredDeviceCapabilities</key>
+	<array>
+		<string>arm64</string>
+	</array>
+	<key>LSRequiresIPhoneOS</key>
+	<true/>
+	<key>CFBundleShortVersionString</key>
+	<string>` + version + `</string>
+	<key>CFBundleVersion</key>
+	<string>` + buildNumber + `</string>
+</dict>
+</plist>
+`
+}
+
+// mobileprovision returns a provisioning profile: the property list wrapped into (fake) CMS signature bytes
+func mobileprovision(name, applicationID, teamID string) string {
+	return "0\x80\x06\t*\x86H\x86\xf7\r\x01\x07\x02\xa0\x800\x80" + `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>AppIDName</key>
+	<string>Bitrise App</string>
+	<key>Entitlements</key>
+	<dict>
+		<key>application-identifier</key>
+		<string>` + applicationID + `</string>
+		<key>keychain-access-groups</key>
+		<array>
+			<string>` + teamID + `.*</string>
+		</array>
+		<key>get-task-allow</key>
+		<false/>
+		<key>com.apple.developer.team-identifier</key>
+		<string>` + teamID + `</string>
+	</dict>
+	<key>ExpirationDate</key>
+	<date>2027-01-01T00:00:00Z</date>
+	<key>Name</key>
+	<string>` + name + `</string>
+	<key>ProvisionsAllDevices</key>
+	<true/>
+	<key>TeamIdentifier</key>
+	<array>
+		<string>` + teamID + `</string>
+	</array>
+	<key>Version</key>
+	<integer>1</integer>
+</dict>
+</plist>` + "\x00\x00\xa0\x82"
+}
+
+func testAppFiles() map[string]string {
+	return map[string]string{
+		"Info.plist":                                          infoPlist("io.bitrise.app", "1.2.0", "42"),
+		"embedded.mobileprovision":                            mobileprovision("App Store io.bitrise.app", "ABCDE12345.io.bitrise.app", "ABCDE12345"),
+		"PlugIns/Notification.appex/Info.plist":               infoPlist("io.bitrise.app.notification", "1.2.0", "42"),
+		"PlugIns/Notification.appex/embedded.mobileprovision": mobileprovision("App Store Wildcard", "ABCDE12345.io.bitrise.*", "ABCDE12345"),
+		"PlugIns/Share.appex/Info.plist":                      infoPlist("io.bitrise.app.share", "1.2.0", "42"),
+		"Watch/Watch.app/Info.plist":                          infoPlist("io.bitrise.app.watchkitapp", "1.2.0", "42"),
+		"Watch/Watch.app/embedded.mobileprovision":            mobileprovision("App Store io.bitrise.app", "ABCDE12345.io.bitrise.app", "ABCDE12345"),
+		"Frameworks/Sentry.framework/Info.plist":              infoPlist("io.sentry.Sentry", "8.0.0", "8.0.0"),
+		"Frameworks/Sentry.framework/Sentry":                  "",
+		"www/index.html":                                      "",
+	}
+}
+
+func wantTestAppMetadata(pth string) iosAppMetadata {
+	return iosAppMetadata{
+		Path:                   pth,
+		BundleID:               "io.bitrise.app",
+		Version:                "1.2.0",
+		BuildNumber:            "42",
+		HasProvisioningProfile: true,
+		ProvisioningProfile:    &provisioningProfileInfo{Name: "App Store io.bitrise.app", ApplicationID: "ABCDE12345.io.bitrise.app", TeamID: "ABCDE12345"},
+		AppExtensions: []bundleInfo{
+			{Type: appExtensionBundle, Path: "PlugIns/Notification.appex", BundleID: "io.bitrise.app.notification", Version: "1.2.0", BuildNumber: "42", HasProvisioningProfile: true,
+				ProvisioningProfile: &provisioningProfileInfo{Name: "App Store Wildcard", ApplicationID: "ABCDE12345.io.bitrise.*", TeamID: "ABCDE12345"}},
+			{Type: appExtensionBundle, Path: "PlugIns/Share.appex", BundleID: "io.bitrise.app.share", Version: "1.2.0", BuildNumber: "42", HasProvisioningProfile: false},
+		},
+		WatchApps: []bundleInfo{
+			{Type: watchAppBundle, Path: "Watch/Watch.app", BundleID: "io.bitrise.app.watchkitapp", Version: "1.2.0", BuildNumber: "42", HasProvisioningProfile: true,
+				ProvisioningProfile: &provisioningProfileInfo{Name: "App Store io.bitrise.app", ApplicationID: "ABCDE12345.io.bitrise.app", TeamID: "ABCDE12345"}},
+		},
+		Frameworks: []bundleInfo{
+			{Type: frameworkBundle, Path: "Frameworks/Sentry.framework", BundleID: "io.sentry.Sentry", Version: "8.0.0", BuildNumber: "8.0.0", HasProvisioningProfile: false},
+		},
+	}
+}
+
+func Test_inventoryIOSArtifact(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	appPth := filepath.Join(tmpDir, "App.app")
+	for name, content := range testAppFiles() {
+		pth := filepath.Join(appPth, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(pth), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ipaPth := filepath.Join(tmpDir, "App.ipa")
+	ipaFile, err := os.Create(ipaPth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipWriter := zip.NewWriter(ipaFile)
+	for name, content := range testAppFiles() {
+		w, err := zipWriter.Create("Payload/App.app/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ipaFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pth := range []string{appPth, ipaPth} {
+		t.Run(filepath.Base(pth), func(t *testing.T) {
+			got, err := inventoryIOSArtifact(pth)
+			if err != nil {
+				t.Fatalf("inventoryIOSArtifact() error = %v", err)
+			}
+			if want := wantTestAppMetadata(pth); !reflect.DeepEqual(got, want) {
+				t.Errorf("inventoryIOSArtifact() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func Test_topLevelApps(t *testing.T) {
+	apps := []string{
+		"/build/App.app",
+		"/build/App.app/Watch/Watch.app",
+		"/build/Other.app",
+	}
+	want := []string{"/build/App.app", "/build/Other.app"}
+	if got := topLevelApps(apps); !reflect.DeepEqual(got, want) {
+		t.Errorf("topLevelApps() = %v, want %v", got, want)
+	}
+}
+
+func Test_parseProvisioningProfile(t *testing.T) {
+	got, err := parseProvisioningProfile([]byte(mobileprovision("App Store io.bitrise.app", "ABCDE12345.io.bitrise.app", "ABCDE12345")))
+	if err != nil {
+		t.Fatalf("parseProvisioningProfile() error = %v", err)
+	}
+	want := provisioningProfileInfo{Name: "App Store io.bitrise.app", ApplicationID: "ABCDE12345.io.bitrise.app", TeamID: "ABCDE12345"}
+	if got != want {
+		t.Errorf("parseProvisioningProfile() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseProvisioningProfile([]byte("not a profile")); err == nil {
+		t.Errorf("parseProvisioningProfile() of an invalid profile, want error")
+	}
+}
+
+func Test_provisioningProfileInfo_coversBundleID(t *testing.T) {
+	tests := []struct {
+		applicationID string
+		bundleID      string
+		want          bool
+	}{
+		{applicationID: "ABCDE12345.io.bitrise.app", bundleID: "io.bitrise.app", want: true},
+		{applicationID: "ABCDE12345.io.bitrise.app", bundleID: "io.bitrise.app.share", want: false},
+		{applicationID: "ABCDE12345.io.bitrise.*", bundleID: "io.bitrise.app.share", want: true},
+		{applicationID: "ABCDE12345.io.bitrise.*", bundleID: "io.other.app", want: false},
+		{applicationID: "ABCDE12345.*", bundleID: "io.other.app", want: true},
+		{applicationID: "", bundleID: "io.bitrise.app", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.applicationID+" "+tt.bundleID, func(t *testing.T) {
+			profile := provisioningProfileInfo{ApplicationID: tt.applicationID}
+			if got := profile.coversBundleID(tt.bundleID); got != tt.want {
+				t.Errorf("coversBundleID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_provisioningProfileWarnings(t *testing.T) {
+	appProfile := &provisioningProfileInfo{Name: "App", ApplicationID: "ABCDE12345.io.bitrise.app", TeamID: "ABCDE12345"}
+	metadata := iosAppMetadata{
+		Path:                   "/deploy/App.ipa",
+		BundleID:               "io.bitrise.app",
+		HasProvisioningProfile: true,
+		ProvisioningProfile:    appProfile,
+		AppExtensions: []bundleInfo{
+			{Type: appExtensionBundle, Path: "PlugIns/Notification.appex", BundleID: "io.bitrise.app.notification", HasProvisioningProfile: true,
+				ProvisioningProfile: &provisioningProfileInfo{Name: "Notification", ApplicationID: "ABCDE12345.io.bitrise.app.notification", TeamID: "ABCDE12345"}},
+			{Type: appExtensionBundle, Path: "PlugIns/Share.appex", BundleID: "io.bitrise.app.share", HasProvisioningProfile: true, ProvisioningProfile: appProfile},
+			{Type: appExtensionBundle, Path: "PlugIns/Widget.appex", BundleID: "io.bitrise.app.widget", HasProvisioningProfile: true,
+				ProvisioningProfile: &provisioningProfileInfo{Name: "Widget", ApplicationID: "FGHIJ67890.io.bitrise.app.widget", TeamID: "FGHIJ67890"}},
+			{Type: appExtensionBundle, Path: "PlugIns/Intents.appex", BundleID: "io.bitrise.app.intents"},
+		},
+		Frameworks: []bundleInfo{
+			{Type: frameworkBundle, Path: "Frameworks/Sentry.framework", BundleID: "io.sentry.Sentry"},
+		},
+	}
+
+	tests := []struct {
+		name                        string
+		requireProvisioningProfiles bool
+		want                        []string
+	}{
+		{
+			name:                        "profiles required",
+			requireProvisioningProfiles: true,
+			want: []string{
+				"PlugIns/Share.appex (io.bitrise.app.share) is signed with the provisioning profile App, whose application identifier (ABCDE12345.io.bitrise.app) does not match the bundle id",
+				"PlugIns/Widget.appex is signed with the provisioning profile Widget of team FGHIJ67890, the app's profile is of team ABCDE12345",
+				"PlugIns/Intents.appex has no embedded provisioning profile",
+			},
+		},
+		{
+			name:                        "profiles not required",
+			requireProvisioningProfiles: false,
+			want: []string{
+				"PlugIns/Share.appex (io.bitrise.app.share) is signed with the provisioning profile App, whose application identifier (ABCDE12345.io.bitrise.app) does not match the bundle id",
+				"PlugIns/Widget.appex is signed with the provisioning profile Widget of team FGHIJ67890, the app's profile is of team ABCDE12345",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := provisioningProfileWarnings(metadata, tt.requireProvisioningProfiles); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("provisioningProfileWarnings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
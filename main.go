@@ -3,17 +3,17 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/bitrise-io/go-steputils/jsdependency"
 	"github.com/bitrise-io/go-steputils/stepconf"
 	"github.com/bitrise-io/go-steputils/tools"
 	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/command"
-	"github.com/bitrise-io/go-utils/errorutil"
 	"github.com/bitrise-io/go-utils/log"
 	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/go-utils/sliceutil"
@@ -33,6 +33,17 @@ const (
 
 	apkPathEnvKey = "BITRISE_APK_PATH"
 	aabPathEnvKey = "BITRISE_AAB_PATH"
+
+	mappingPathEnvKey            = "BITRISE_MAPPING_PATH"
+	nativeDebugSymbolsPathEnvKey = "BITRISE_ANDROID_NATIVE_SYMBOLS_PATH"
+
+	ipaPathListEnvKey = "BITRISE_IPA_PATH_LIST"
+	apkPathListEnvKey = "BITRISE_APK_PATH_LIST"
+	aabPathListEnvKey = "BITRISE_AAB_PATH_LIST"
+
+	iosAppMetadataPathEnvKey = "BITRISE_IOS_APP_METADATA_PATH"
+	manifestPathEnvKey       = "BITRISE_CORDOVA_ARCHIVE_MANIFEST_PATH"
+	sizeReportPathEnvKey     = "BITRISE_ARTIFACT_SIZE_REPORT_PATH"
 )
 
 type config struct {
@@ -44,104 +55,206 @@ type config struct {
 	CordovaVersion string `env:"cordova_version"`
 	WorkDir        string `env:"workdir,dir"`
 	Options        string `env:"options"`
-	BuildSystem    string `env:"build_system,opt[legacy,modern]"`
+	BuildSystem    string `env:"build_system,opt[auto,legacy,modern]"`
 	DeployDir      string `env:"BITRISE_DEPLOY_DIR"`
 	UseCache       bool   `env:"cache_local_deps,opt[true,false]"`
+	CacheGradle    bool   `env:"cache_gradle,opt[true,false]"`
+	CachePods      bool   `env:"cache_pods,opt[true,false]"`
+	CachePlatforms bool   `env:"cache_platforms,opt[true,false]"`
 	AndroidAppType string `env:"android_app_type,opt[apk,aab]"`
+	DiscoveryMode  string `env:"artifact_discovery,opt[snapshot,mtime]"`
+	NameTemplate   string `env:"artifact_name_template"`
+	Checksums      string `env:"checksum_sidecars,opt[sha256,'sha256,sha512',none]"`
+
+	InstallDependencies bool `env:"install_dependencies,opt[true,false]"`
+
+	MaxArtifactSizeMB float64  `env:"max_artifact_size_mb"`
+	SizeBudgets       []string `env:"size_budgets_mb,multiline"`
+
+	BundletoolPath             string          `env:"bundletool_path"`
+	BundletoolKeystorePath     string          `env:"bundletool_keystore_path"`
+	BundletoolKeystorePassword stepconf.Secret `env:"bundletool_keystore_password"`
+	BundletoolKeyAlias         string          `env:"bundletool_key_alias"`
+	BundletoolKeyPassword      stepconf.Secret `env:"bundletool_key_password"`
+
+	JavaHome string `env:"java_home"`
+
+	RetryCount    int `env:"retry_count,range[0..10]"`
+	RetryWaitTime int `env:"retry_wait_time,range[1..600]"`
 }
 
-func installDependency(packageManager jsdependency.Tool, name string, version string) error {
-	cmdSlice, err := jsdependency.InstallGlobalDependencyCommand(packageManager, name, version)
-	if err != nil {
-		return fmt.Errorf("Failed to update %s version, error: %s", name, err)
-	}
-	for i, cmd := range cmdSlice {
+func installDependency(retry retrier, packageManager jsPackageManager, name string, version string) error {
+	args := globalInstallArgs(packageManager, name+"@"+version)
+
+	return retry.do("Installing "+name, func() (string, error) {
+		cmd, err := command.NewFromSlice(args)
+		if err != nil {
+			return "", fmt.Errorf("Failed to update %s version, error: %s", name, err)
+		}
+
 		fmt.Println()
-		log.Donef("$ %s", cmd.Slice.PrintableCommandArgs())
+		log.Donef("$ %s", cmd.PrintableCommandArgs())
 		fmt.Println()
 
-		// Yarn returns an error if the package is not added before removal, ignoring
-		if out, err := cmd.Slice.RunAndReturnTrimmedCombinedOutput(); err != nil && !(packageManager == jsdependency.Yarn && i == 0) {
-			if errorutil.IsExitStatusError(err) {
-				return fmt.Errorf("Failed to update %s version: %s failed, output: %s", name, cmd.Slice.PrintableCommandArgs(), out)
-			}
-			return fmt.Errorf("Failed to update %s version: %s failed, error: %s", name, cmd.Slice.PrintableCommandArgs(), err)
+		if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+			return installRetryReason(out, err), fmt.Errorf("Failed to update %s version: %s", name, globalInstallError(packageManager, cmd.PrintableCommandArgs(), out, err))
 		}
-	}
-	return nil
+		return "", nil
+	})
 }
 
-func moveAndExportOutputs(outputs []string, deployDir, envKey string, isOnlyContent bool) (string, error) {
-	outputToExport := ""
+// exportedOutput is a build output copied to the deploy dir
+type exportedOutput struct {
+	SourcePth string
+	Pth       string
+	// Digests holds the checksums of exported files by algorithm
+	Digests map[string]string
+}
+
+// selectOutput picks the output exported as the single-value output:
+// the first output, in path order, whose file name contains "universal" (universal APK of ABI splits),
+// otherwise the first output in path order.
+func selectOutput(outputs []exportedOutput) exportedOutput {
 	for _, output := range outputs {
+		if strings.Contains(filepath.Base(output.SourcePth), "universal") {
+			return output
+		}
+	}
+	return outputs[0]
+}
+
+// outputExporter copies the build outputs to the deploy dir
+type outputExporter struct {
+	deployDir          string
+	namer              *artifactNamer
+	manifest           *artifactManifest
+	checksumAlgorithms []string
+}
+
+// exportFile checksums an exported file, writes its checksum sidecars and adds it to the manifest
+func (e outputExporter) exportFile(platform, artifactType, sourcePth, pth, bundlePth string) (map[string]string, error) {
+	digests, err := fileDigests(pth, append([]string{sha256Checksum}, e.checksumAlgorithms...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeChecksumSidecars(pth, digests, e.checksumAlgorithms); err != nil {
+		return nil, err
+	}
+
+	if err := e.manifest.add(platform, artifactType, sourcePth, pth, bundlePth, digests[sha256Checksum]); err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+// moveAndExportOutputs copies the outputs to the deploy dir, and exports the path of the output selected by selectOutput.
+// If listEnvKey is set, the pipe separated list of every copied output's path is exported too.
+func (e outputExporter) moveAndExportOutputs(outputs []string, platform, artifactType, envKey, listEnvKey string, isOnlyContent bool) ([]exportedOutput, exportedOutput, error) {
+	sortedOutputs := append([]string{}, outputs...)
+	sort.Strings(sortedOutputs)
+
+	var exported []exportedOutput
+	for _, sourcePth := range sortedOutputs {
+		output := sourcePth
 		info, err := os.Lstat(output)
 		if err != nil {
-			return "", err
+			return nil, exportedOutput{}, err
 		}
 
 		if info.Mode()&os.ModeSymlink != 0 {
 			resolvedPth, err := os.Readlink(output)
 			if err != nil {
-				return "", err
+				return nil, exportedOutput{}, err
 			}
 
 			if exist, err := pathutil.IsPathExists(resolvedPth); err != nil {
-				return "", err
+				return nil, exportedOutput{}, err
 			} else if !exist {
-				return "", fmt.Errorf("resolved path: %s does not exist", resolvedPth)
+				return nil, exportedOutput{}, fmt.Errorf("resolved path: %s does not exist", resolvedPth)
 			}
 
 			resolvedInfo, err := os.Lstat(resolvedPth)
 			if err != nil {
-				return "", err
+				return nil, exportedOutput{}, err
 			}
 
 			if resolvedInfo.Mode()&os.ModeSymlink != 0 {
-				return "", fmt.Errorf("resolved path: %s is still symlink", resolvedPth)
+				return nil, exportedOutput{}, fmt.Errorf("resolved path: %s is still symlink", resolvedPth)
 			}
 
 			output = resolvedPth
 			info = resolvedInfo
 		}
 
-		fileName := filepath.Base(output)
-		destinationPth := filepath.Join(deployDir, fileName)
+		destinationPth := filepath.Join(e.deployDir, e.namer.name(output, platform))
+		var digests map[string]string
 
 		if info.IsDir() {
 			if err := command.CopyDir(output, destinationPth, isOnlyContent); err != nil {
-				return "", err
+				return nil, exportedOutput{}, err
 			}
 		} else {
 			if err := command.CopyFile(output, destinationPth); err != nil {
-				return "", err
+				return nil, exportedOutput{}, err
+			}
+
+			bundlePth := ""
+			if artifactType == ipaArtifact {
+				bundlePth = destinationPth
+			}
+			if digests, err = e.exportFile(platform, artifactType, sourcePth, destinationPth, bundlePth); err != nil {
+				return nil, exportedOutput{}, err
 			}
 		}
 
-		outputToExport = destinationPth
+		exported = append(exported, exportedOutput{SourcePth: sourcePth, Pth: destinationPth, Digests: digests})
 	}
 
-	if outputToExport == "" {
-		return "", nil
+	if len(exported) == 0 {
+		return nil, exportedOutput{}, nil
 	}
 
-	if err := tools.ExportEnvironmentWithEnvman(envKey, outputToExport); err != nil {
-		return "", err
+	selected := selectOutput(exported)
+	if err := tools.ExportEnvironmentWithEnvman(envKey, selected.Pth); err != nil {
+		return nil, exportedOutput{}, err
 	}
 
-	return outputToExport, nil
+	if selected.Digests != nil {
+		if err := exportChecksums(envKey, selected.Digests, e.checksumAlgorithms); err != nil {
+			return nil, exportedOutput{}, err
+		}
+	}
+
+	if listEnvKey != "" {
+		if err := tools.ExportEnvironmentWithEnvman(listEnvKey, strings.Join(exportedPaths(exported), "|")); err != nil {
+			return nil, exportedOutput{}, err
+		}
+	}
+
+	return exported, selected, nil
 }
 
-func findArtifact(rootDir, ext string, buildStart time.Time) ([]string, error) {
+func exportedPaths(outputs []exportedOutput) []string {
+	var pths []string
+	for _, output := range outputs {
+		pths = append(pths, output.Pth)
+	}
+	return pths
+}
+
+func findArtifact(rootDir, ext string, filter buildProductFilter) ([]string, error) {
 	var matches []string
 	if walkErr := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
-		if info.ModTime().Before(buildStart) {
+		if err != nil {
+			log.Warnf("Failed to read %s: %s", path, err)
 			return nil
 		}
 
-		if filepath.Ext(path) == "."+ext {
+		if filepath.Ext(path) == "."+ext && filter.IsBuildProduct(path, info) {
 			matches = append(matches, path)
 		}
-		return err
+		return nil
 	}); walkErr != nil {
 		return nil, walkErr
 	}
@@ -180,6 +293,20 @@ func main() {
 	fmt.Println()
 	stepconf.Print(configs)
 
+	if configs.JavaHome != "" {
+		if exist, err := pathutil.IsDirExists(configs.JavaHome); err != nil {
+			fail("Failed to check if java_home (%s) exists, error: %s", configs.JavaHome, err)
+		} else if !exist {
+			fail("java_home (%s) does not exist", configs.JavaHome)
+		}
+	}
+
+	if configs.BundletoolPath != "" && configs.AndroidAppType != "aab" {
+		log.Warnf("bundletool_path is set, but android_app_type is not aab: no universal apk will be generated")
+	}
+
+	retry := newRetrier(configs.RetryCount, configs.RetryWaitTime)
+
 	// Change dir to working directory
 	workDir, err := pathutil.AbsPath(configs.WorkDir)
 	log.Debugf("New work dir: %s", workDir)
@@ -209,67 +336,189 @@ func main() {
 		}()
 	}
 
-	// Update cordova version
-	if configs.CordovaVersion != "" {
-		log.Printf("\n")
-		log.Infof("Updating cordova version to: %s", configs.CordovaVersion)
-
-		packageManager, err := jsdependency.DetectTool(workDir)
-		if err != nil {
-			log.Warnf("%s", err)
+	platforms := []string{}
+	if configs.Platform != "" {
+		platformsSplit := strings.Split(configs.Platform, ",")
+		for _, platform := range platformsSplit {
+			platforms = append(platforms, strings.TrimSpace(platform))
 		}
-		log.Printf("Js package manager used: %s", packageManager)
+	}
 
-		if err := installDependency(packageManager, "cordova", configs.CordovaVersion); err != nil {
-			fail("Updating cordova failed, error: %s", err)
+	// checked before installing anything, an unsupported Node.js version fails the installs with unrelated errors
+	fmt.Println()
+	log.Infof("Checking Node.js version")
+	if err := checkNodeCompatibility(workDir, platforms); err != nil {
+		fail("%s", err)
+	}
+
+	if configs.InstallDependencies {
+		fmt.Println()
+		log.Infof("Installing project dependencies")
+		if err := installProjectDependencies(workDir); err != nil {
+			fail("Failed to install project dependencies, error: %s", err)
 		}
 	}
 
-	// Print cordova and ionic version
-	cordovaVersion, err := cordova.CurrentVersion()
+	// Prefer the cordova CLI pinned by the project
+	cordovaBinary, err := cordova.LocalBinary(workDir)
 	if err != nil {
-		fail(err.Error())
+		log.Warnf("Failed to check the project's cordova dependency: %s", err)
+	}
+
+	var cordovaVersion string
+	if cordovaBinary != nil {
+		if cordovaVersion, err = cordova.BinaryVersion(cordovaBinary...); err != nil {
+			log.Warnf("The project pins cordova in package.json, but its cordova CLI is not available: %s", err)
+			log.Warnf("Falling back to the global cordova CLI, install the project dependencies to use the pinned version")
+			cordovaBinary = nil
+		} else if configs.CordovaVersion != "" {
+			log.Warnf("The project pins cordova in package.json, the cordova version input (%s) is ignored", configs.CordovaVersion)
+		}
+	}
+
+	if cordovaBinary == nil {
+		// Update cordova version
+		if configs.CordovaVersion != "" {
+			log.Printf("\n")
+			log.Infof("Updating cordova version to: %s", configs.CordovaVersion)
+
+			installedVersion, err := cordova.CurrentVersion()
+			if err != nil {
+				log.Warnf("Failed to get the installed cordova version: %s", err)
+			}
+
+			satisfied, err := isVersionSatisfied(installedVersion, configs.CordovaVersion, func() (string, error) {
+				return latestPackageVersion("cordova")
+			})
+			if err != nil {
+				log.Warnf("Failed to check the installed cordova version against %s: %s", configs.CordovaVersion, err)
+			}
+
+			if satisfied {
+				log.Printf("The installed cordova version (%s) satisfies %s, skipping update", installedVersion, configs.CordovaVersion)
+			} else {
+				packageManager, err := detectPackageManager(workDir)
+				if err != nil {
+					log.Warnf("%s", err)
+				}
+				log.Printf("Js package manager used: %s", packageManager)
+
+				if err := installDependency(retry, packageManager, "cordova", configs.CordovaVersion); err != nil {
+					fail("Updating cordova failed, error: %s", err)
+				}
+
+				// the global bin dir of the package manager may be preceded by an other cordova install in the PATH
+				if configs.CordovaVersion != latestVersion {
+					if updatedVersion, err := cordova.CurrentVersion(); err == nil {
+						if satisfied, err := isVersionSatisfied(updatedVersion, configs.CordovaVersion, nil); err == nil && !satisfied {
+							log.Warnf("The cordova in the PATH (%s) does not satisfy %s, check that the global bin directory of %s is in the PATH", updatedVersion, configs.CordovaVersion, packageManager)
+						}
+					}
+				}
+			}
+		}
+
+		if cordovaVersion, err = cordova.CurrentVersion(); err != nil {
+			fail(err.Error())
+		}
 	}
 
+	// Print cordova and ionic version
 	fmt.Println()
+	if cordovaBinary != nil {
+		log.Printf("Using the project's cordova CLI: %s", strings.Join(cordovaBinary, " "))
+	} else {
+		log.Printf("Using the global cordova CLI")
+	}
 	log.Printf("Using cordova version:\n%s", colorstring.Green(cordovaVersion))
 
 	// Fulfill cordova builder
 	builder := cordova.New()
+	if cordovaBinary != nil {
+		builder.SetBinary(cordovaBinary...)
+	}
 
-	platforms := []string{}
-	if configs.Platform != "" {
-		platformsSplit := strings.Split(configs.Platform, ",")
-		for _, platform := range platformsSplit {
-			platforms = append(platforms, strings.TrimSpace(platform))
+	if len(platforms) > 0 {
+		builder.SetPlatforms(platforms...)
+	}
+
+	// removed dirs are re-created by cordova prepare,
+	// if it runs in a previous step, the dirs were already set up for the current project
+	if configs.CachePlatforms && configs.RunPrepare {
+		fmt.Println()
+		log.Infof("Checking the restored platforms and plugins directories")
+		if err := invalidatePlatformsCache(workDir, platforms); err != nil {
+			fail("Failed to invalidate the platforms and plugins directories, error: %s", err)
 		}
+	}
 
-		builder.SetPlatforms(platforms...)
+	if err := checkCordovaCLINodeCompatibility(cordovaVersion); err != nil {
+		fail("%s", err)
 	}
 
 	builder.SetAndroidAppType(configs.AndroidAppType)
 	builder.SetConfiguration(configs.Configuration)
 	builder.SetTarget(configs.Target)
 
+	var options []string
+	if sliceutil.IsStringInSlice("ios", platforms) {
+		cordovaIOSVersion, err := cordova.PlatformVersion(workDir, "ios")
+		if err != nil {
+			log.Warnf("Failed to detect cordova-ios version: %s", err)
+		}
+		if cordovaIOSVersion != nil {
+			log.Printf("Detected cordova-ios version: %s", cordovaIOSVersion)
+		}
+
+		buildSystemOpt, err := buildSystemOption(configs.BuildSystem, cordovaIOSVersion)
+		if err != nil {
+			fail("Invalid Xcode build system: %s", err)
+		}
+		if buildSystemOpt != "" {
+			// general option, needs to precede the platform-specific options
+			options = append(options, buildSystemOpt)
+		}
+	}
+
 	if configs.Options != "" {
-		options, err := shellquote.Split(configs.Options)
+		customOptions, err := shellquote.Split(configs.Options)
 		if err != nil {
 			fail("Failed to shell split Options (%s), error: %s", configs.Options, err)
 		}
 
-		builder.SetCustomOptions(options...)
+		options = append(options, customOptions...)
 	}
 
-	if configs.BuildSystem == "legacy" {
-		legacyQuery := "--buildFlag='-UseModernBuildSystem=0'"
-		builder.SetCustomOptions(legacyQuery)
-	} else if configs.BuildSystem == "modern" {
-		modernQuery := "--buildFlag='-UseModernBuildSystem=1'"
-		builder.SetCustomOptions(modernQuery)
-	}
+	builder.SetCustomOptions(options...)
 
 	builder.SetBuildConfig(configs.BuildConfig)
 
+	projectConfig, err := cordova.ParseConfig(filepath.Join(workDir, "config.xml"))
+	if err != nil {
+		log.Warnf("Failed to read the app metadata from config.xml: %s", err)
+	}
+
+	namer, err := newArtifactNamer(configs.NameTemplate, projectConfig, configs.Configuration, configs.DeployDir)
+	if err != nil {
+		fail("Failed to set up the artifact naming (%s), error: %s", configs.NameTemplate, err)
+	}
+	checksumAlgorithms, err := parseChecksumAlgorithms(configs.Checksums)
+	if err != nil {
+		fail("Invalid checksum sidecars (%s), error: %s", configs.Checksums, err)
+	}
+
+	budgets, err := parseSizeBudgets(configs.MaxArtifactSizeMB, configs.SizeBudgets)
+	if err != nil {
+		fail("Invalid size budgets, error: %s", err)
+	}
+
+	manifest := newArtifactManifest(configs.Configuration, configs.Target, projectConfig)
+	exporter := outputExporter{deployDir: configs.DeployDir, namer: namer, manifest: manifest, checksumAlgorithms: checksumAlgorithms}
+
+	if configs.CachePods && sliceutil.IsStringInSlice("ios", platforms) {
+		logRestoredPods(workDir)
+	}
+
 	// cordova prepare
 	if configs.RunPrepare {
 		fmt.Println()
@@ -283,22 +532,66 @@ func main() {
 		}
 	}
 
+	if sliceutil.IsStringInSlice("android", platforms) {
+		fmt.Println()
+		log.Infof("Checking the Android build environment")
+		if err := androidJDKPreflight(workDir, configs.JavaHome); err != nil {
+			fail("%s", err)
+		}
+		androidSDKPreflight(workDir)
+	}
+
 	// cordova build
 	fmt.Println()
 	log.Infof("Building project")
 
-	buildCmd := builder.CompileCommand()
-	buildCmd.SetStdout(os.Stdout)
-	buildCmd.SetStderr(os.Stderr)
+	// a command can only run once, every attempt runs a new one
+	newBuildCmd := func(output *outputRecorder) *command.Model {
+		buildCmd := builder.CompileCommand()
+		buildCmd.SetStdout(io.MultiWriter(os.Stdout, output))
+		buildCmd.SetStderr(io.MultiWriter(os.Stderr, output))
+		if configs.JavaHome != "" {
+			buildCmd.AppendEnvs(javaHomeEnvs(configs.JavaHome)...)
+		}
+		return buildCmd
+	}
 
-	log.Donef("$ %s", buildCmd.PrintableCommandArgs())
+	var snapshotRoots []string
+	var snapshotBefore outputSnapshot
+	if configs.DiscoveryMode == snapshotDiscovery {
+		snapshotRoots, err = outputSnapshotRoots(workDir)
+		if err != nil {
+			fail("Failed to list build output dirs, error: %s", err)
+		}
+		snapshotBefore = takeOutputSnapshot(snapshotRoots, nil)
+	}
 
 	compileStart := time.Now()
 
-	if err := buildCmd.Run(); err != nil {
+	if err := retry.do("cordova build", func() (string, error) {
+		output := &outputRecorder{}
+		buildCmd := newBuildCmd(output)
+		log.Donef("$ %s", buildCmd.PrintableCommandArgs())
+
+		if err := buildCmd.Run(); err != nil {
+			// only network and registry failures are retried, a failing build is not fixed by a retry
+			return transientFailureReason(output.String()), err
+		}
+		return "", nil
+	}); err != nil {
 		fail("cordova build failed, error: %s", err)
 	}
 
+	var buildProducts buildProductFilter = modTimeFilter{buildStart: compileStart}
+	if configs.DiscoveryMode == snapshotDiscovery {
+		// output dirs might have been created by the compile
+		snapshotRoots, err = outputSnapshotRoots(workDir)
+		if err != nil {
+			fail("Failed to list build output dirs, error: %s", err)
+		}
+		buildProducts = newSnapshotFilter(snapshotBefore, takeOutputSnapshot(snapshotRoots, snapshotBefore))
+	}
+
 	// collect outputs
 	var ipas, apps []string
 
@@ -309,28 +602,32 @@ func main() {
 		log.Infof("Collecting iOS outputs")
 		log.Printf("iOS output directory: %s", iosOutputDir)
 
-		ipas, err = findArtifact(iosOutputDir, "ipa", compileStart)
+		ipas, err = findArtifact(iosOutputDir, "ipa", buildProducts)
 		if err != nil {
 			fail("Failed to find ipas in dir (%s), error: %s", iosOutputDir, err)
 		}
 
+		var exportedIpas []exportedOutput
 		if configs.Target == "device" && len(ipas) > 0 {
-			if exportedPth, err := moveAndExportOutputs(ipas, configs.DeployDir, ipaPathEnvKey, false); err != nil {
+			if exported, selected, err := exporter.moveAndExportOutputs(ipas, "ios", ipaArtifact, ipaPathEnvKey, ipaPathListEnvKey, false); err != nil {
 				fail("Failed to export ipas, error: %s", err)
 			} else {
-				log.Donef("The ipa path is now available in the Environment Variable: %s (value: %s)", ipaPathEnvKey, exportedPth)
+				exportedIpas = exported
+				log.Donef("The ipa path is now available in the Environment Variable: %s (value: %s)", ipaPathEnvKey, selected.Pth)
+				log.Donef("The ipa paths are now available in the Environment Variable: %s (value: %s)", ipaPathListEnvKey, strings.Join(exportedPaths(exported), "|"))
 			}
 		}
 
-		dsyms, err := findArtifact(iosOutputDir, "dSYM", compileStart)
+		dsyms, err := findArtifact(iosOutputDir, "dSYM", buildProducts)
 		if err != nil {
 			fail("Failed to find dSYMs in dir (%s), error: %s", iosOutputDir, err)
 		}
 
 		if len(dsyms) > 0 {
-			if exportedPth, err := moveAndExportOutputs(dsyms, configs.DeployDir, dsymDirPathEnvKey, true); err != nil {
+			if _, selected, err := exporter.moveAndExportOutputs(dsyms, "ios", dsymArtifact, dsymDirPathEnvKey, "", true); err != nil {
 				fail("Failed to export dsyms, error: %s", err)
 			} else {
+				exportedPth := selected.Pth
 				log.Donef("The dsym dir path is now available in the Environment Variable: %s (value: %s)", dsymDirPathEnvKey, exportedPth)
 
 				zippedExportedPth := exportedPth + ".zip"
@@ -338,23 +635,35 @@ func main() {
 					fail("Failed to zip dsym dir (%s), error: %s", exportedPth, err)
 				}
 
+				digests, err := exporter.exportFile("ios", dsymArtifact, selected.SourcePth, zippedExportedPth, "")
+				if err != nil {
+					fail("Failed to checksum dsym.zip (%s), error: %s", zippedExportedPth, err)
+				}
+
 				if err := tools.ExportEnvironmentWithEnvman(dsymZipPathEnvKey, zippedExportedPth); err != nil {
 					fail("Failed to export dsym.zip (%s), error: %s", zippedExportedPth, err)
 				}
 
+				if err := exportChecksums(dsymZipPathEnvKey, digests, exporter.checksumAlgorithms); err != nil {
+					fail("Failed to export dsym.zip (%s) checksums, error: %s", zippedExportedPth, err)
+				}
+
 				log.Donef("The dsym.zip path is now available in the Environment Variable: %s (value: %s)", dsymZipPathEnvKey, zippedExportedPth)
 			}
 		}
 
-		apps, err = findArtifact(iosOutputDir, "app", compileStart)
+		apps, err = findArtifact(iosOutputDir, "app", buildProducts)
 		if err != nil {
 			fail("Failed to find apps in dir (%s), error: %s", iosOutputDir, err)
 		}
 
+		var exportedApps []exportedOutput
 		if configs.Target == "emulator" && len(apps) > 0 {
-			if exportedPth, err := moveAndExportOutputs(apps, configs.DeployDir, appDirPathEnvKey, true); err != nil {
+			if exported, selected, err := exporter.moveAndExportOutputs(topLevelApps(apps), "ios", appArtifact, appDirPathEnvKey, "", true); err != nil {
 				fail("Failed to export apps, error: %s", err)
 			} else {
+				exportedApps = exported
+				exportedPth := selected.Pth
 				log.Donef("The app dir path is now available in the Environment Variable: %s (value: %s)", appDirPathEnvKey, exportedPth)
 
 				zippedExportedPth := exportedPth + ".zip"
@@ -362,13 +671,47 @@ func main() {
 					fail("Failed to zip app dir (%s), error: %s", exportedPth, err)
 				}
 
+				digests, err := exporter.exportFile("ios", appArtifact, selected.SourcePth, zippedExportedPth, exportedPth)
+				if err != nil {
+					fail("Failed to checksum app.zip (%s), error: %s", zippedExportedPth, err)
+				}
+
 				if err := tools.ExportEnvironmentWithEnvman(appZipPathEnvKey, zippedExportedPth); err != nil {
 					fail("Failed to export app.zip (%s), error: %s", zippedExportedPth, err)
 				}
 
+				if err := exportChecksums(appZipPathEnvKey, digests, exporter.checksumAlgorithms); err != nil {
+					fail("Failed to export app.zip (%s) checksums, error: %s", zippedExportedPth, err)
+				}
+
 				log.Donef("The app.zip path is now available in the Environment Variable: %s (value: %s)", appZipPathEnvKey, zippedExportedPth)
 			}
 		}
+
+		inventoried := exportedPaths(exportedIpas)
+		if configs.Target == "emulator" {
+			inventoried = exportedPaths(exportedApps)
+		}
+
+		if len(inventoried) > 0 {
+			fmt.Println()
+			log.Infof("Collecting iOS app inventory")
+
+			metadataList, metadataPth, err := exportIOSAppMetadata(inventoried, configs.DeployDir, configs.Target == "device")
+			if err != nil {
+				fail("Failed to export iOS app metadata, error: %s", err)
+			}
+
+			for _, metadata := range metadataList {
+				manifest.setIOSAppMetadata(metadata)
+			}
+
+			if err := tools.ExportEnvironmentWithEnvman(iosAppMetadataPathEnvKey, metadataPth); err != nil {
+				fail("Failed to export iOS app metadata (%s), error: %s", metadataPth, err)
+			}
+
+			log.Donef("The iOS app metadata path is now available in the Environment Variable: %s (value: %s)", iosAppMetadataPathEnvKey, metadataPth)
+		}
 	}
 
 	var apks, aabs []string
@@ -386,29 +729,121 @@ func main() {
 		fmt.Println()
 		log.Infof("Collecting android outputs")
 
-		apks, err = findArtifact(androidOutputDir, "apk", compileStart)
+		apks, err = findArtifact(androidOutputDir, "apk", buildProducts)
 		if err != nil {
 			fail("Failed to find apks in dir (%s), error: %s", androidOutputDir, err)
 		}
 
 		if len(apks) > 0 {
-			if exportedPth, err := moveAndExportOutputs(apks, configs.DeployDir, apkPathEnvKey, false); err != nil {
+			if exported, selected, err := exporter.moveAndExportOutputs(apks, "android", apkArtifact, apkPathEnvKey, apkPathListEnvKey, false); err != nil {
 				fail("Failed to export apks, error: %s", err)
 			} else {
-				log.Donef("The apk path is now available in the Environment Variable: %s (value: %s)", apkPathEnvKey, exportedPth)
+				log.Donef("The apk path is now available in the Environment Variable: %s (value: %s)", apkPathEnvKey, selected.Pth)
+				log.Donef("The apk paths are now available in the Environment Variable: %s (value: %s)", apkPathListEnvKey, strings.Join(exportedPaths(exported), "|"))
 			}
 		}
 
-		aabs, err = findArtifact(androidOutputDir, "aab", compileStart)
+		aabs, err = findArtifact(androidOutputDir, "aab", buildProducts)
 		if err != nil {
 			fail("Failed to find aab in dir (%s), error: %s", androidOutputDir, err)
 		}
 
 		if len(aabs) > 0 {
-			if exportedPth, err := moveAndExportOutputs(aabs, configs.DeployDir, aabPathEnvKey, false); err != nil {
+			if exported, selected, err := exporter.moveAndExportOutputs(aabs, "android", aabArtifact, aabPathEnvKey, aabPathListEnvKey, false); err != nil {
 				fail("Failed to export aabs, error: %s", err)
 			} else {
-				log.Donef("The aab path is now available in the Environment Variable: %s (value: %s)", aabPathEnvKey, exportedPth)
+				log.Donef("The aab path is now available in the Environment Variable: %s (value: %s)", aabPathEnvKey, selected.Pth)
+				log.Donef("The aab paths are now available in the Environment Variable: %s (value: %s)", aabPathListEnvKey, strings.Join(exportedPaths(exported), "|"))
+
+				if configs.BundletoolPath != "" && len(apks) == 0 {
+					fmt.Println()
+					log.Infof("Generating universal apk")
+
+					apkPth := filepath.Join(configs.DeployDir, namer.name(strings.TrimSuffix(selected.Pth, filepath.Ext(selected.Pth))+"-universal.apk", "android"))
+					signing := bundletoolSigning{
+						KeystorePth:      configs.BundletoolKeystorePath,
+						KeystorePassword: string(configs.BundletoolKeystorePassword),
+						KeyAlias:         configs.BundletoolKeyAlias,
+						KeyPassword:      string(configs.BundletoolKeyPassword),
+					}
+					if err := generateUniversalAPK(configs.BundletoolPath, selected.Pth, apkPth, signing); err != nil {
+						fail("Failed to generate universal apk from %s, error: %s", selected.Pth, err)
+					}
+
+					digests, err := exporter.exportFile("android", apkArtifact, selected.Pth, apkPth, "")
+					if err != nil {
+						fail("Failed to checksum universal apk (%s), error: %s", apkPth, err)
+					}
+
+					for _, envKey := range []string{apkPathEnvKey, apkPathListEnvKey} {
+						if err := tools.ExportEnvironmentWithEnvman(envKey, apkPth); err != nil {
+							fail("Failed to export universal apk (%s), error: %s", apkPth, err)
+						}
+					}
+
+					if err := exportChecksums(apkPathEnvKey, digests, exporter.checksumAlgorithms); err != nil {
+						fail("Failed to export universal apk (%s) checksums, error: %s", apkPth, err)
+					}
+
+					log.Donef("The universal apk path is now available in the Environment Variable: %s (value: %s)", apkPathEnvKey, apkPth)
+				}
+			}
+		}
+
+		mappings, err := findMappingFiles(androidOutputDir, configs.Configuration, buildProducts)
+		if err != nil {
+			fail("Failed to find mapping files in dir (%s), error: %s", androidOutputDir, err)
+		}
+
+		if len(mappings) > 0 {
+			if exported, selected, err := exporter.moveAndExportOutputs(mappings, "android", mappingArtifact, mappingPathEnvKey, "", false); err != nil {
+				fail("Failed to export mapping files, error: %s", err)
+			} else {
+				for _, mapping := range exported {
+					log.Printf("Mapping file: %s (from %s)", mapping.Pth, mapping.SourcePth)
+				}
+				log.Donef("The mapping file path is now available in the Environment Variable: %s (value: %s)", mappingPathEnvKey, selected.Pth)
+			}
+		}
+
+		nativeLibDirs, err := findNativeLibDirs(androidOutputDir, configs.Configuration)
+		if err != nil {
+			fail("Failed to find native libraries in dir (%s), error: %s", androidOutputDir, err)
+		}
+
+		nativeDebugSymbolsPth := ""
+		for _, nativeLibDir := range nativeLibDirs {
+			libs, err := nativeDebugSymbols(nativeLibDir, buildProducts)
+			if err != nil {
+				fail("Failed to find native libraries in dir (%s), error: %s", nativeLibDir, err)
+			}
+			if len(libs) == 0 {
+				continue
+			}
+
+			zipPth := filepath.Join(configs.DeployDir, namer.name(nativeDebugSymbolsFileName, "android"))
+			if err := zipNativeDebugSymbols(libs, zipPth); err != nil {
+				fail("Failed to zip native debug symbols of %s, error: %s", nativeLibDir, err)
+			}
+			log.Printf("Native debug symbols: %s (from %s)", zipPth, nativeLibDir)
+
+			digests, err := exporter.exportFile("android", nativeDebugSymbolsArtifact, nativeLibDir, zipPth, "")
+			if err != nil {
+				fail("Failed to checksum native debug symbols (%s), error: %s", zipPth, err)
+			}
+
+			if nativeDebugSymbolsPth == "" {
+				nativeDebugSymbolsPth = zipPth
+
+				if err := tools.ExportEnvironmentWithEnvman(nativeDebugSymbolsPathEnvKey, zipPth); err != nil {
+					fail("Failed to export native debug symbols (%s), error: %s", zipPth, err)
+				}
+
+				if err := exportChecksums(nativeDebugSymbolsPathEnvKey, digests, exporter.checksumAlgorithms); err != nil {
+					fail("Failed to export native debug symbols (%s) checksums, error: %s", zipPth, err)
+				}
+
+				log.Donef("The native debug symbols path is now available in the Environment Variable: %s (value: %s)", nativeDebugSymbolsPathEnvKey, zipPth)
 			}
 		}
 	}
@@ -422,9 +857,79 @@ func main() {
 		fail("Build outputs missing: %s", err)
 	}
 
+	manifestPth, err := manifest.write(configs.DeployDir)
+	if err != nil {
+		fail("Failed to write the artifact manifest, error: %s", err)
+	}
+
+	if err := tools.ExportEnvironmentWithEnvman(manifestPathEnvKey, manifestPth); err != nil {
+		fail("Failed to export the artifact manifest (%s), error: %s", manifestPth, err)
+	}
+
+	fmt.Println()
+	log.Donef("The artifact manifest path is now available in the Environment Variable: %s (value: %s)", manifestPathEnvKey, manifestPth)
+
+	fmt.Println()
+	log.Infof("Artifact sizes")
+
+	report := sizeReport{Artifacts: []artifactSize{}}
+	var budgetViolations []string
+	for _, artifact := range manifest.Artifacts {
+		if artifact.Type != apkArtifact && artifact.Type != aabArtifact && artifact.Type != ipaArtifact {
+			continue
+		}
+
+		size, err := measureArtifact(artifact.DeployPath, artifact.Type)
+		if err != nil {
+			log.Warnf("Failed to measure %s: %s", artifact.DeployPath, err)
+			continue
+		}
+
+		logSizeTable(size)
+		report.Artifacts = append(report.Artifacts, size)
+		budgetViolations = append(budgetViolations, budgets.check(size)...)
+	}
+
+	sizeReportPth, err := report.write(configs.DeployDir)
+	if err != nil {
+		fail("Failed to write the size report, error: %s", err)
+	}
+
+	if err := tools.ExportEnvironmentWithEnvman(sizeReportPathEnvKey, sizeReportPth); err != nil {
+		fail("Failed to export the size report (%s), error: %s", sizeReportPth, err)
+	}
+
+	log.Donef("The size report path is now available in the Environment Variable: %s (value: %s)", sizeReportPathEnvKey, sizeReportPth)
+
 	if configs.UseCache {
 		if err := cacheNpm(workDir); err != nil {
 			log.Warnf("Failed to mark files for caching, error: %s", err)
 		}
 	}
+
+	if configs.CacheGradle && sliceutil.IsStringInSlice("android", platforms) {
+		if err := cacheGradle(workDir); err != nil {
+			log.Warnf("Failed to mark Gradle files for caching, error: %s", err)
+		}
+	}
+
+	if configs.CachePods && sliceutil.IsStringInSlice("ios", platforms) {
+		if err := cachePods(workDir); err != nil {
+			log.Warnf("Failed to mark CocoaPods files for caching, error: %s", err)
+		}
+	}
+
+	if configs.CachePlatforms {
+		if err := cachePlatforms(workDir); err != nil {
+			log.Warnf("Failed to mark platforms and plugins directories for caching, error: %s", err)
+		}
+	}
+
+	if len(budgetViolations) > 0 {
+		fmt.Println()
+		for _, violation := range budgetViolations {
+			log.Errorf("%s", violation)
+		}
+		fail("Artifact size budget exceeded")
+	}
 }
@@ -157,3 +157,41 @@ func Test_checkBuildProducts(t *testing.T) {
 		})
 	}
 }
+
+func Test_selectOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs []exportedOutput
+		want    string
+	}{
+		{
+			"Single output",
+			[]exportedOutput{{SourcePth: "/apk/release/app-release.apk", Pth: "/deploy/app-release.apk"}},
+			"/deploy/app-release.apk",
+		},
+		{
+			"ABI splits with universal APK",
+			[]exportedOutput{
+				{SourcePth: "/apk/release/app-arm64-v8a-release.apk", Pth: "/deploy/app-arm64-v8a-release.apk"},
+				{SourcePth: "/apk/release/app-armeabi-v7a-release.apk", Pth: "/deploy/app-armeabi-v7a-release.apk"},
+				{SourcePth: "/apk/release/app-universal-release.apk", Pth: "/deploy/app-universal-release.apk"},
+			},
+			"/deploy/app-universal-release.apk",
+		},
+		{
+			"Flavors",
+			[]exportedOutput{
+				{SourcePth: "/apk/free/release/app-free-release.apk", Pth: "/deploy/app-free-release.apk"},
+				{SourcePth: "/apk/paid/release/app-paid-release.apk", Pth: "/deploy/app-paid-release.apk"},
+			},
+			"/deploy/app-free-release.apk",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectOutput(tt.outputs); got.Pth != tt.want {
+				t.Errorf("selectOutput() = %v, want %v", got.Pth, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-cordova-archive/cordova"
+	"github.com/bitrise-steplib/steps-cordova-archive/semver"
+)
+
+// nodeRequirement is a Node.js version requirement of the project
+type nodeRequirement struct {
+	source      string
+	requirement string
+}
+
+// minimumNodeVersions lists the minimum Node.js version (the engines.node of the package) by major version
+var minimumNodeVersions = map[string]map[int]string{
+	"cordova": {
+		10: "10.0.0",
+		11: "12.0.0",
+		12: "16.13.0",
+	},
+	"cordova-android": {
+		10: "12.0.0",
+		11: "12.0.0",
+		12: "16.13.0",
+		13: "16.13.0",
+		14: "20.17.0",
+	},
+	"cordova-ios": {
+		6: "10.0.0",
+		7: "16.13.0",
+	},
+}
+
+// currentNodeVersion returns the version of the node binary in the PATH
+func currentNodeVersion() (semver.Version, error) {
+	cmd := command.New("node", "-v")
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("$ %s failed, output: %s, error: %s", cmd.PrintableCommandArgs(), out, err)
+	}
+	return semver.Parse(out)
+}
+
+// projectNodeRequirements returns the Node.js versions required by the project's .nvmrc, .node-version and package.json engines.node.
+// Version aliases (like lts/*) are not returned, as they can not be checked.
+func projectNodeRequirements(workDir string) ([]nodeRequirement, error) {
+	var requirements []nodeRequirement
+	for _, name := range []string{".nvmrc", ".node-version"} {
+		content, err := os.ReadFile(filepath.Join(workDir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		requirement := strings.TrimSpace(strings.SplitN(string(content), "\n", 2)[0])
+		if _, err := semver.ParseRange(requirement); err != nil {
+			continue
+		}
+		requirements = append(requirements, nodeRequirement{source: name, requirement: requirement})
+	}
+
+	content, err := os.ReadFile(filepath.Join(workDir, "package.json"))
+	if os.IsNotExist(err) {
+		return requirements, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var packageJSON struct {
+		Engines map[string]string `json:"engines"`
+	}
+	if err := json.Unmarshal(content, &packageJSON); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %s", err)
+	}
+	if requirement := packageJSON.Engines["node"]; requirement != "" {
+		requirements = append(requirements, nodeRequirement{source: "package.json engines.node", requirement: requirement})
+	}
+	return requirements, nil
+}
+
+// checkNodeVersion compares the Node.js version with the project's requirements and the minimum versions of the cordova packages.
+// Not satisfied project requirements are returned as warnings, a Node.js version below the minimum of a cordova package as an error.
+func checkNodeVersion(node semver.Version, requirements []nodeRequirement, packageVersions map[string]*semver.Version) ([]string, error) {
+	var warnings []string
+	for _, requirement := range requirements {
+		versionRange, err := semver.ParseRange(requirement.requirement)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Invalid Node.js version requirement in %s: %s", requirement.source, err))
+			continue
+		}
+		if !versionRange.Contains(node) {
+			warnings = append(warnings, fmt.Sprintf("Node.js %s does not satisfy %s required by %s", node, requirement.requirement, requirement.source))
+		}
+	}
+
+	var unsupported []string
+	for _, packageName := range []string{"cordova", "cordova-android", "cordova-ios"} {
+		version := packageVersions[packageName]
+		if version == nil {
+			continue
+		}
+
+		minimum, ok := minimumNodeVersions[packageName][version.Major]
+		if !ok {
+			continue
+		}
+		if node.LessThan(semver.MustParse(minimum)) {
+			unsupported = append(unsupported, fmt.Sprintf("%s %s requires Node.js %s or later", packageName, version, minimum))
+		}
+	}
+
+	if len(unsupported) > 0 {
+		return warnings, fmt.Errorf("Node.js %s is not supported: %s", node, strings.Join(unsupported, ", "))
+	}
+	return warnings, nil
+}
+
+// checkNodeCompatibility checks the Node.js version against the requirements of the project (and its workspace root),
+// and the minimum Node.js versions of the cordova CLI and platforms the project depends on.
+// It only reads the project files, so it can run before any dependency is installed.
+func checkNodeCompatibility(workDir string, platforms []string) error {
+	node, err := currentNodeVersion()
+	if err != nil {
+		log.Warnf("Failed to get the Node.js version: %s", err)
+		return nil
+	}
+	log.Printf("Node.js version: %s", node)
+
+	requirementDirs := []string{workDir}
+	if workspace, err := findJSWorkspace(workDir); err != nil {
+		log.Warnf("Failed to look for the js workspace: %s", err)
+	} else if workspace != nil && workspace.root != workDir {
+		requirementDirs = append(requirementDirs, workspace.root)
+	}
+
+	var requirements []nodeRequirement
+	for _, dir := range requirementDirs {
+		dirRequirements, err := projectNodeRequirements(dir)
+		if err != nil {
+			log.Warnf("Failed to read the Node.js version requirements of %s: %s", dir, err)
+			continue
+		}
+		requirements = append(requirements, dirRequirements...)
+	}
+
+	packageVersions := map[string]*semver.Version{}
+	if version, err := cordova.PackageVersion(workDir, "cordova"); err != nil {
+		log.Warnf("Failed to detect the cordova version of the project: %s", err)
+	} else if version != nil {
+		packageVersions["cordova"] = version
+	}
+	for _, platform := range platforms {
+		version, err := cordova.PlatformVersion(workDir, platform)
+		if err != nil {
+			log.Warnf("Failed to detect cordova-%s version: %s", platform, err)
+			continue
+		}
+		packageVersions["cordova-"+platform] = version
+	}
+
+	warnings, err := checkNodeVersion(node, requirements, packageVersions)
+	for _, warning := range warnings {
+		log.Warnf("%s", warning)
+	}
+	return err
+}
+
+// checkCordovaCLINodeCompatibility checks the Node.js version against the minimum Node.js version of the cordova CLI in use
+func checkCordovaCLINodeCompatibility(cordovaVersion string) error {
+	version, err := parseCordovaVersion(cordovaVersion)
+	if err != nil {
+		return nil
+	}
+
+	node, err := currentNodeVersion()
+	if err != nil {
+		log.Warnf("Failed to get the Node.js version: %s", err)
+		return nil
+	}
+
+	_, err = checkNodeVersion(node, nil, map[string]*semver.Version{"cordova": &version})
+	return err
+}
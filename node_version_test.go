@@ -0,0 +1,84 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-cordova-archive/semver"
+)
+
+func Test_projectNodeRequirements(t *testing.T) {
+	workDir := t.TempDir()
+	writeTestFile(t, filepath.Join(workDir, ".nvmrc"), "v18.17.0\n")
+	writeTestFile(t, filepath.Join(workDir, ".node-version"), "lts/hydrogen\n")
+	writeTestFile(t, filepath.Join(workDir, "package.json"), `{"engines": {"node": ">=16.13.0"}}`)
+
+	got, err := projectNodeRequirements(workDir)
+	if err != nil {
+		t.Fatalf("projectNodeRequirements() error = %v", err)
+	}
+
+	want := []nodeRequirement{
+		{source: ".nvmrc", requirement: "v18.17.0"},
+		{source: "package.json engines.node", requirement: ">=16.13.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("projectNodeRequirements() = %v, want %v", got, want)
+	}
+}
+
+func Test_checkNodeVersion(t *testing.T) {
+	version := func(s string) *semver.Version {
+		v := semver.MustParse(s)
+		return &v
+	}
+
+	tests := []struct {
+		name            string
+		node            string
+		requirements    []nodeRequirement
+		packageVersions map[string]*semver.Version
+		wantWarnings    int
+		wantErr         string
+	}{
+		{
+			name:            "compatible",
+			node:            "18.17.0",
+			requirements:    []nodeRequirement{{source: ".nvmrc", requirement: "18"}},
+			packageVersions: map[string]*semver.Version{"cordova": version("12.0.0"), "cordova-android": version("12.0.1"), "cordova-ios": version("7.0.0")},
+		},
+		{
+			name:         "project requirement not satisfied",
+			node:         "20.5.0",
+			requirements: []nodeRequirement{{source: ".nvmrc", requirement: "18"}, {source: "package.json engines.node", requirement: ">=16"}},
+			wantWarnings: 1,
+		},
+		{
+			name:            "cordova requires newer node",
+			node:            "14.21.3",
+			packageVersions: map[string]*semver.Version{"cordova": version("12.0.0"), "cordova-ios": version("6.3.0")},
+			wantErr:         "cordova 12.0.0 requires Node.js 16.13.0 or later",
+		},
+		{
+			name:            "unknown package version",
+			node:            "14.21.3",
+			packageVersions: map[string]*semver.Version{"cordova-android": version("99.0.0"), "cordova-ios": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := checkNodeVersion(semver.MustParse(tt.node), tt.requirements, tt.packageVersions)
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("checkNodeVersion() warnings = %v, want %d", warnings, tt.wantWarnings)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("checkNodeVersion() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkNodeVersion() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
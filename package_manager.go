@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/jsdependency"
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// Package managers not known by jsdependency
+const (
+	pnpm jsdependency.Tool = "pnpm"
+	bun  jsdependency.Tool = "bun"
+)
+
+// jsPackageManager is the package manager of a project
+type jsPackageManager struct {
+	tool jsdependency.Tool
+	// yarnBerry is true for Yarn 2+ projects
+	yarnBerry bool
+	// yarnMajorVersion is the Yarn major version pinned by the project, 0 if unknown
+	yarnMajorVersion int
+}
+
+func (m jsPackageManager) String() string {
+	if m.yarnBerry {
+		return "yarn (berry)"
+	}
+	return string(m.tool)
+}
+
+// detectPackageManager returns the package manager of the project dir:
+// pnpm if pnpm-lock.yaml exists, bun if bun.lockb (or the text based bun.lock) exists, otherwise yarn or npm as detected by jsdependency.
+// Yarn 2+ is recognized by the .yarnrc.yml, the packageManager field of the package.json or the yarn.lock format.
+func detectPackageManager(dir string) (jsPackageManager, error) {
+	for _, lockfile := range []struct {
+		name string
+		tool jsdependency.Tool
+	}{{"pnpm-lock.yaml", pnpm}, {"bun.lockb", bun}, {"bun.lock", bun}} {
+		if exist, err := pathutil.IsPathExists(filepath.Join(dir, lockfile.name)); err != nil {
+			return jsPackageManager{tool: jsdependency.Npm}, err
+		} else if exist {
+			return jsPackageManager{tool: lockfile.tool}, nil
+		}
+	}
+
+	tool, err := jsdependency.DetectTool(dir)
+	if err != nil {
+		return jsPackageManager{tool: tool}, err
+	}
+	manager := jsPackageManager{tool: tool}
+
+	pinnedTool, pinnedMajor, err := packageManagerField(dir)
+	if err != nil {
+		return manager, err
+	}
+	if pinnedTool == pnpm || pinnedTool == bun {
+		manager.tool = pinnedTool
+		return manager, nil
+	}
+	if tool != jsdependency.Yarn && pinnedTool != jsdependency.Yarn {
+		return manager, nil
+	}
+
+	manager.tool = jsdependency.Yarn
+	if pinnedTool == jsdependency.Yarn {
+		manager.yarnMajorVersion = pinnedMajor
+	}
+
+	if manager.yarnMajorVersion >= 2 {
+		manager.yarnBerry = true
+	} else if exist, err := pathutil.IsPathExists(filepath.Join(dir, ".yarnrc.yml")); err != nil {
+		return manager, err
+	} else if exist {
+		manager.yarnBerry = true
+	} else if manager.yarnBerry, err = isBerryLockfile(filepath.Join(dir, "yarn.lock")); err != nil {
+		return manager, err
+	}
+	return manager, nil
+}
+
+// packageManagerField returns the tool and major version of the package.json packageManager field (for example yarn@3.6.1)
+func packageManagerField(dir string) (jsdependency.Tool, int, error) {
+	content, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if os.IsNotExist(err) {
+		return "", 0, nil
+	} else if err != nil {
+		return "", 0, err
+	}
+
+	var packageJSON struct {
+		PackageManager string `json:"packageManager"`
+	}
+	if err := json.Unmarshal(content, &packageJSON); err != nil {
+		return "", 0, err
+	}
+
+	split := strings.SplitN(packageJSON.PackageManager, "@", 2)
+	if len(split) != 2 {
+		return "", 0, nil
+	}
+
+	major, err := strconv.Atoi(strings.SplitN(split[1], ".", 2)[0])
+	if err != nil {
+		return jsdependency.Tool(split[0]), 0, nil
+	}
+	return jsdependency.Tool(split[0]), major, nil
+}
+
+// isBerryLockfile returns true if the yarn.lock was written by Yarn 2+, which stores a __metadata entry
+func isBerryLockfile(pth string) (bool, error) {
+	content, err := os.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(content), "\n__metadata:"), nil
+}
+
+// readYarnrcSettings returns the top level scalar settings of a .yarnrc.yml
+func readYarnrcSettings(pth string) (map[string]string, error) {
+	f, err := os.Open(pth)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", pth, err)
+		}
+	}()
+
+	settings := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		split := strings.SplitN(line, ":", 2)
+		if len(split) != 2 {
+			continue
+		}
+		settings[strings.TrimSpace(split[0])] = strings.Trim(strings.TrimSpace(split[1]), `'"`)
+	}
+	return settings, scanner.Err()
+}
+
+// yarnBerryCacheDirs returns the Yarn 2+ package cache (the project's .yarn/cache, or the global cache)
+// and the unplugged packages dir of Plug'n'Play installs
+func yarnBerryCacheDirs(root string, yarnMajorVersion int) ([]string, error) {
+	settings, err := readYarnrcSettings(filepath.Join(root, ".yarnrc.yml"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Yarn 4 uses the global cache by default
+	globalCache := yarnMajorVersion >= 4
+	if value, ok := settings["enableGlobalCache"]; ok {
+		globalCache = value == "true"
+	}
+
+	var cacheDir string
+	switch {
+	case settings["cacheFolder"] != "":
+		cacheDir = settings["cacheFolder"]
+	case globalCache:
+		cacheDir = filepath.Join(pathutil.UserHomeDir(), ".yarn", "berry", "cache")
+	default:
+		cacheDir = filepath.Join(".yarn", "cache")
+	}
+	if !filepath.IsAbs(cacheDir) {
+		cacheDir = filepath.Join(root, cacheDir)
+	}
+
+	return []string{cacheDir, filepath.Join(root, ".yarn", "unplugged")}, nil
+}
+
+// pnpmStoreDir returns the content-addressable store of pnpm
+func pnpmStoreDir(root string) string {
+	storeDir, err := command.New("pnpm", "store", "path").SetDir(root).RunAndReturnTrimmedOutput()
+	if err == nil && storeDir != "" {
+		return storeDir
+	}
+	log.Warnf("Failed to get the pnpm store path, using the default location: %s", err)
+
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "pnpm", "store")
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(pathutil.UserHomeDir(), "Library", "pnpm", "store")
+	}
+	return filepath.Join(pathutil.UserHomeDir(), ".local", "share", "pnpm", "store")
+}
+
+// bunCacheDir returns the global install cache of bun
+func bunCacheDir() string {
+	if cacheDir := os.Getenv("BUN_INSTALL_CACHE_DIR"); cacheDir != "" {
+		return cacheDir
+	}
+	return filepath.Join(pathutil.UserHomeDir(), ".bun", "install", "cache")
+}
+
+// installDependenciesArgs returns the lockfile respecting install command of the package manager.
+// npm ci requires a package-lock.json, without it npm install is used.
+func installDependenciesArgs(manager jsPackageManager, hasLockfile bool) []string {
+	switch {
+	case manager.tool == pnpm:
+		return []string{"pnpm", "install", "--frozen-lockfile"}
+	case manager.tool == bun:
+		return []string{"bun", "install", "--frozen-lockfile"}
+	case manager.yarnBerry:
+		return []string{"yarn", "install", "--immutable"}
+	case manager.tool == jsdependency.Yarn:
+		return []string{"yarn", "install", "--frozen-lockfile"}
+	case hasLockfile:
+		return []string{"npm", "ci"}
+	}
+	return []string{"npm", "install"}
+}
+
+// npmrcRegistry returns the registry set in the first .npmrc of the dirs, which sets it.
+// Environment variable references (${NPM_REGISTRY}) are expanded.
+func npmrcRegistry(dirs ...string) (string, error) {
+	for _, dir := range dirs {
+		content, err := os.ReadFile(filepath.Join(dir, ".npmrc"))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			split := strings.SplitN(line, "=", 2)
+			if len(split) == 2 && strings.TrimSpace(split[0]) == "registry" {
+				return os.ExpandEnv(strings.TrimSpace(split[1])), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// installDependencyEnvs returns the environment setting the registry for the package manager.
+// npm, pnpm and Yarn 1 read the npm_config_registry, Yarn 2+ does not read .npmrc files, its registry is set by YARN_NPM_REGISTRY_SERVER.
+func installDependencyEnvs(manager jsPackageManager, registry string) []string {
+	if registry == "" {
+		return nil
+	}
+	if manager.yarnBerry {
+		return []string{"YARN_NPM_REGISTRY_SERVER=" + registry}
+	}
+	return []string{"npm_config_registry=" + registry}
+}
+
+// installedDependenciesRestored returns true if the installed dependencies of the root were restored from a cache matching the lockfile:
+// the node_modules, or the .pnp.cjs of Yarn Plug'n'Play installs.
+// A restored package cache (like the Yarn 2+ cache) alone is not enough, the install still needs to link the packages.
+func installedDependenciesRestored(root string, dependencyCache keyedCache) (bool, error) {
+	if restored, err := dependencyCache.isRestored(); err != nil || !restored {
+		return false, err
+	}
+	if dependencyCache.keyDir == filepath.Join(root, "node_modules") {
+		return true, nil
+	}
+
+	for _, name := range []string{".pnp.cjs", ".pnp.js"} {
+		if exist, err := pathutil.IsPathExists(filepath.Join(root, name)); err != nil {
+			return false, err
+		} else if exist {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// installProjectDependencies installs the dependencies of the project (or of its workspace) with the lockfile respecting install command.
+// The install is skipped if the installed dependencies were restored from a cache matching the lockfile.
+func installProjectDependencies(workdir string) error {
+	root := workdir
+	if workspace, err := findJSWorkspace(workdir); err != nil {
+		return err
+	} else if workspace != nil {
+		root = workspace.root
+	}
+
+	dependencyCache, err := jsDependencyCache(workdir)
+	if err != nil {
+		return err
+	}
+	if restored, err := installedDependenciesRestored(root, dependencyCache); err != nil {
+		return err
+	} else if restored {
+		log.Printf("The restored dependencies match the lockfile, skipping install")
+		return nil
+	}
+
+	manager, err := detectPackageManager(root)
+	if err != nil {
+		log.Warnf("%s", err)
+	}
+	log.Printf("Js package manager used: %s", manager)
+
+	lockfile, err := findLockfile(root)
+	if err != nil {
+		return err
+	}
+	if lockfile == "" {
+		log.Warnf("No lockfile found in %s, the installed dependency versions are not locked", root)
+	}
+
+	registry, err := npmrcRegistry(workdir, root)
+	if err != nil {
+		return fmt.Errorf("failed to read .npmrc: %s", err)
+	}
+
+	if manager.yarnBerry && registry != "" {
+		// a registry set in .yarnrc.yml takes precedence
+		settings, err := readYarnrcSettings(filepath.Join(root, ".yarnrc.yml"))
+		if err != nil {
+			return err
+		}
+		if settings["npmRegistryServer"] != "" {
+			registry = ""
+		}
+	}
+	if registry != "" {
+		log.Printf("Using registry from .npmrc: %s", registry)
+	}
+
+	cmd, err := command.NewFromSlice(installDependenciesArgs(manager, lockfile != ""))
+	if err != nil {
+		return err
+	}
+	cmd.SetDir(root).SetStdout(os.Stdout).SetStderr(os.Stderr)
+	cmd.AppendEnvs(installDependencyEnvs(manager, registry)...)
+
+	log.Donef("$ %s", cmd.PrintableCommandArgs())
+	return cmd.Run()
+}
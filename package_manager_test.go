@@ -0,0 +1,221 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-steputils/jsdependency"
+)
+
+func Test_detectPackageManager(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  jsPackageManager
+	}{
+		{
+			name:  "npm",
+			files: map[string]string{"package.json": `{}`, "package-lock.json": `{}`},
+			want:  jsPackageManager{tool: jsdependency.Npm},
+		},
+		{
+			name:  "yarn classic",
+			files: map[string]string{"package.json": `{}`, "yarn.lock": "# yarn lockfile v1\n\ncordova@^12.0.0:\n"},
+			want:  jsPackageManager{tool: jsdependency.Yarn},
+		},
+		{
+			name:  "yarn berry lockfile",
+			files: map[string]string{"package.json": `{}`, "yarn.lock": "# This file is generated by running \"yarn install\"\n\n__metadata:\n  version: 6\n"},
+			want:  jsPackageManager{tool: jsdependency.Yarn, yarnBerry: true},
+		},
+		{
+			name:  "yarn berry yarnrc",
+			files: map[string]string{"package.json": `{}`, "yarn.lock": "", ".yarnrc.yml": "nodeLinker: pnp\n"},
+			want:  jsPackageManager{tool: jsdependency.Yarn, yarnBerry: true},
+		},
+		{
+			name:  "yarn pinned by packageManager",
+			files: map[string]string{"package.json": `{"packageManager": "yarn@4.0.2"}`},
+			want:  jsPackageManager{tool: jsdependency.Yarn, yarnBerry: true, yarnMajorVersion: 4},
+		},
+		{
+			name:  "pnpm",
+			files: map[string]string{"package.json": `{}`, "pnpm-lock.yaml": "lockfileVersion: '6.0'\n"},
+			want:  jsPackageManager{tool: pnpm},
+		},
+		{
+			name:  "bun",
+			files: map[string]string{"package.json": `{}`, "bun.lockb": "\x00"},
+			want:  jsPackageManager{tool: bun},
+		},
+		{
+			name:  "pnpm pinned by packageManager",
+			files: map[string]string{"package.json": `{"packageManager": "pnpm@8.6.0"}`},
+			want:  jsPackageManager{tool: pnpm},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for pth, content := range tt.files {
+				writeTestFile(t, filepath.Join(dir, pth), content)
+			}
+
+			got, err := detectPackageManager(dir)
+			if err != nil {
+				t.Fatalf("detectPackageManager() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("detectPackageManager() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_yarnBerryCacheDirs(t *testing.T) {
+	tests := []struct {
+		name             string
+		yarnrc           string
+		yarnMajorVersion int
+		wantCacheDir     string
+	}{
+		{name: "yarn 3 default", yarnMajorVersion: 3, wantCacheDir: "<root>/.yarn/cache"},
+		{name: "yarn 4 default", yarnMajorVersion: 4, wantCacheDir: "<home>/.yarn/berry/cache"},
+		{name: "local cache enabled", yarnrc: "enableGlobalCache: false\n", yarnMajorVersion: 4, wantCacheDir: "<root>/.yarn/cache"},
+		{name: "custom cache folder", yarnrc: "cacheFolder: \"./yarn-cache\"\n", yarnMajorVersion: 3, wantCacheDir: "<root>/yarn-cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			if tt.yarnrc != "" {
+				writeTestFile(t, filepath.Join(root, ".yarnrc.yml"), tt.yarnrc)
+			}
+
+			got, err := yarnBerryCacheDirs(root, tt.yarnMajorVersion)
+			if err != nil {
+				t.Fatalf("yarnBerryCacheDirs() error = %v", err)
+			}
+
+			wantCacheDir := tt.wantCacheDir
+			for placeholder, dir := range map[string]string{"<root>": root, "<home>": home} {
+				if strings.HasPrefix(wantCacheDir, placeholder) {
+					wantCacheDir = filepath.Join(dir, wantCacheDir[len(placeholder):])
+				}
+			}
+			want := []string{wantCacheDir, filepath.Join(root, ".yarn", "unplugged")}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("yarnBerryCacheDirs() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_installDependenciesArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		manager     jsPackageManager
+		hasLockfile bool
+		want        []string
+	}{
+		{name: "npm", manager: jsPackageManager{tool: jsdependency.Npm}, hasLockfile: true, want: []string{"npm", "ci"}},
+		{name: "npm without lockfile", manager: jsPackageManager{tool: jsdependency.Npm}, want: []string{"npm", "install"}},
+		{name: "yarn", manager: jsPackageManager{tool: jsdependency.Yarn}, hasLockfile: true, want: []string{"yarn", "install", "--frozen-lockfile"}},
+		{name: "yarn berry", manager: jsPackageManager{tool: jsdependency.Yarn, yarnBerry: true}, hasLockfile: true, want: []string{"yarn", "install", "--immutable"}},
+		{name: "pnpm", manager: jsPackageManager{tool: pnpm}, hasLockfile: true, want: []string{"pnpm", "install", "--frozen-lockfile"}},
+		{name: "bun", manager: jsPackageManager{tool: bun}, hasLockfile: true, want: []string{"bun", "install", "--frozen-lockfile"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := installDependenciesArgs(tt.manager, tt.hasLockfile); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("installDependenciesArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_npmrcRegistry(t *testing.T) {
+	projectDir := t.TempDir()
+	rootDir := t.TempDir()
+	t.Setenv("TEST_REGISTRY_HOST", "npm.example.com")
+	writeTestFile(t, filepath.Join(projectDir, ".npmrc"), "save-exact=true\n")
+	writeTestFile(t, filepath.Join(rootDir, ".npmrc"), "@acme:registry=https://acme.example.com/\nregistry = https://${TEST_REGISTRY_HOST}/\n")
+
+	got, err := npmrcRegistry(projectDir, rootDir)
+	if err != nil {
+		t.Fatalf("npmrcRegistry() error = %v", err)
+	}
+	if want := "https://npm.example.com/"; got != want {
+		t.Errorf("npmrcRegistry() = %v, want %v", got, want)
+	}
+}
+
+func Test_installDependencyEnvs(t *testing.T) {
+	registry := "https://npm.example.com/"
+	if got := installDependencyEnvs(jsPackageManager{tool: jsdependency.Npm}, registry); !reflect.DeepEqual(got, []string{"npm_config_registry=" + registry}) {
+		t.Errorf("installDependencyEnvs() = %v", got)
+	}
+	if got := installDependencyEnvs(jsPackageManager{tool: jsdependency.Yarn, yarnBerry: true}, registry); !reflect.DeepEqual(got, []string{"YARN_NPM_REGISTRY_SERVER=" + registry}) {
+		t.Errorf("installDependencyEnvs() = %v", got)
+	}
+	if got := installDependencyEnvs(jsPackageManager{tool: jsdependency.Npm}, ""); got != nil {
+		t.Errorf("installDependencyEnvs() = %v, want nil", got)
+	}
+}
+
+func Test_installedDependenciesRestored(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyDir    string
+		storedKey string
+		files     []string
+		want      bool
+	}{
+		{
+			name:      "restored node_modules",
+			keyDir:    "node_modules",
+			storedKey: "key",
+			want:      true,
+		},
+		{
+			name:      "outdated node_modules",
+			keyDir:    "node_modules",
+			storedKey: "old key",
+			want:      false,
+		},
+		{
+			name:      "restored Yarn package cache only",
+			keyDir:    ".yarn/cache",
+			storedKey: "key",
+			want:      false,
+		},
+		{
+			name:      "restored Yarn package cache of a Plug'n'Play install",
+			keyDir:    ".yarn/cache",
+			storedKey: "key",
+			files:     []string{".pnp.cjs"},
+			want:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			keyDir := filepath.Join(root, tt.keyDir)
+			writeTestFile(t, filepath.Join(keyDir, cacheKeyFileName), tt.storedKey+"\n")
+			for _, file := range tt.files {
+				writeTestFile(t, filepath.Join(root, file), "")
+			}
+
+			got, err := installedDependenciesRestored(root, keyedCache{keyDir: keyDir, key: "key"})
+			if err != nil {
+				t.Fatalf("installedDependenciesRestored() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("installedDependenciesRestored() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
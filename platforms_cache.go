@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// platformsCache returns the cache of the cordova platforms and plugins dirs, without the build outputs,
+// keyed on config.xml, package.json and the lockfile
+func platformsCache(workdir string) (keyedCache, error) {
+	keyFiles := []string{filepath.Join(workdir, "config.xml"), filepath.Join(workdir, "package.json")}
+
+	lockfile, err := projectLockfile(workdir)
+	if err != nil {
+		return keyedCache{}, err
+	}
+	if lockfile != "" {
+		keyFiles = append(keyFiles, lockfile)
+	}
+
+	key, err := cacheKey(keyFiles...)
+	if err != nil {
+		return keyedCache{}, fmt.Errorf("failed to checksum the project files: %s", err)
+	}
+
+	platformsDir := filepath.Join(workdir, "platforms")
+	return keyedCache{
+		name:    "Cordova platforms",
+		keyDir:  platformsDir,
+		include: []string{platformsDir, filepath.Join(workdir, "plugins")},
+		exclude: platformBuildOutputs(platformsDir),
+		key:     key,
+	}, nil
+}
+
+// platformBuildOutputs returns the build output and Gradle state dirs of the platforms:
+// Gradle and Xcode intermediates, signed apk, aab and ipa files and dSYMs, which are not part of the generated project
+func platformBuildOutputs(platformsDir string) []string {
+	androidDir := filepath.Join(platformsDir, "android")
+	return []string{
+		filepath.Join(platformsDir, "*", "build"),
+		filepath.Join(androidDir, "app", "build"),
+		filepath.Join(androidDir, "CordovaLib", "build"),
+		filepath.Join(androidDir, ".gradle"),
+	}
+}
+
+// isPlatformDirComplete checks the files cordova-android and cordova-ios generate when adding the platform,
+// platform dirs of other platforms are considered complete
+func isPlatformDirComplete(platformDir, platform string) (bool, error) {
+	switch platform {
+	case "android":
+		if exist, err := pathutil.IsPathExists(filepath.Join(platformDir, "build.gradle")); err != nil || !exist {
+			return false, err
+		}
+
+		// cordova-android 10+ generates cdv-gradle-config.json, older versions project.properties
+		for _, name := range []string{"cdv-gradle-config.json", "project.properties"} {
+			if exist, err := pathutil.IsPathExists(filepath.Join(platformDir, name)); err != nil || exist {
+				return exist, err
+			}
+		}
+		return false, nil
+	case "ios":
+		projects, err := filepath.Glob(filepath.Join(platformDir, "*.xcodeproj", "project.pbxproj"))
+		if err != nil {
+			return false, err
+		}
+		return len(projects) > 0, nil
+	}
+	return true, nil
+}
+
+// removeIncompletePlatformDirs removes the restored platform dirs missing the files of a completely added platform.
+// Returns false if any of the platform dirs was missing or removed.
+func removeIncompletePlatformDirs(workdir string, platforms []string) (bool, error) {
+	allComplete := true
+	for _, platform := range platforms {
+		platformDir := filepath.Join(workdir, "platforms", platform)
+		if exist, err := pathutil.IsDirExists(platformDir); err != nil {
+			return false, err
+		} else if !exist {
+			log.Printf("Cordova platforms: the %s platform directory was not restored", platform)
+			allComplete = false
+			continue
+		}
+
+		complete, err := isPlatformDirComplete(platformDir, platform)
+		if err != nil {
+			return false, err
+		}
+		if complete {
+			continue
+		}
+
+		log.Warnf("Cordova platforms: the restored %s platform directory is incomplete, removing it", platform)
+		if err := os.RemoveAll(platformDir); err != nil {
+			return false, err
+		}
+		allComplete = false
+	}
+	return allComplete, nil
+}
+
+// invalidatePlatformsCache removes the restored platforms and plugins dirs if they were cached for a different key,
+// and the incomplete platform dirs otherwise, so that cordova prepare re-creates them.
+func invalidatePlatformsCache(workdir string, platforms []string) error {
+	platformsDir := filepath.Join(workdir, "platforms")
+	if exist, err := pathutil.IsDirExists(platformsDir); err != nil {
+		return err
+	} else if !exist {
+		log.Printf("Cordova platforms: no platforms directory restored")
+		return nil
+	}
+
+	items, err := platformsCache(workdir)
+	if err != nil {
+		return err
+	}
+
+	storedKey, err := storedCacheKey(platformsDir)
+	if err != nil {
+		return err
+	}
+	if storedKey == "" {
+		// not restored from the cache, the platforms and plugins dirs are part of the repository
+		return nil
+	}
+
+	if storedKey != items.key {
+		log.Printf("Cordova platforms: the restored cache does not match the project, removing the platforms and plugins directories")
+		for _, dir := range items.include {
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	complete, err := removeIncompletePlatformDirs(workdir, platforms)
+	if err != nil {
+		return err
+	}
+	if !complete {
+		// make sure the re-created platform dirs get cached
+		return os.Remove(filepath.Join(platformsDir, cacheKeyFileName))
+	}
+
+	log.Printf("Cordova platforms: the restored cache matches the project")
+	return nil
+}
+
+// cachePlatforms marks the platforms and plugins dirs for caching, keyed on the project configuration
+func cachePlatforms(workdir string) error {
+	platformsDir := filepath.Join(workdir, "platforms")
+
+	switch exist, err := pathutil.IsDirExists(platformsDir); {
+	case err != nil:
+		return fmt.Errorf("failed to check directory existence, error: %s", err)
+	case !exist:
+		return fmt.Errorf("platforms directory does not exist: %s", platformsDir)
+	}
+
+	items, err := platformsCache(workdir)
+	if err != nil {
+		return err
+	}
+
+	if err := items.commit(); err != nil {
+		return fmt.Errorf("failed to mark platforms and plugins directories to be cached, error: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_isPlatformDirComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform string
+		files    []string
+		want     bool
+	}{
+		{name: "android", platform: "android", files: []string{"build.gradle", "cdv-gradle-config.json"}, want: true},
+		{name: "legacy android", platform: "android", files: []string{"build.gradle", "project.properties"}, want: true},
+		{name: "android without build.gradle", platform: "android", files: []string{"cdv-gradle-config.json"}, want: false},
+		{name: "android without gradle config", platform: "android", files: []string{"build.gradle"}, want: false},
+		{name: "ios", platform: "ios", files: []string{"App.xcodeproj/project.pbxproj", "ios.json"}, want: true},
+		{name: "ios without project", platform: "ios", files: []string{"ios.json"}, want: false},
+		{name: "other platform", platform: "browser", files: nil, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platformDir := t.TempDir()
+			for _, file := range tt.files {
+				writeTestFile(t, filepath.Join(platformDir, file), "")
+			}
+
+			got, err := isPlatformDirComplete(platformDir, tt.platform)
+			if err != nil {
+				t.Fatalf("isPlatformDirComplete() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isPlatformDirComplete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_invalidatePlatformsCache(t *testing.T) {
+	setup := func(t *testing.T, storedKey func(key string) string) string {
+		workDir := t.TempDir()
+		writeTestFile(t, filepath.Join(workDir, "config.xml"), "<widget/>")
+		writeTestFile(t, filepath.Join(workDir, "package.json"), "{}")
+		writeTestFile(t, filepath.Join(workDir, "plugins", "fetch.json"), "{}")
+		writeTestFile(t, filepath.Join(workDir, "platforms", "android", "build.gradle"), "")
+		writeTestFile(t, filepath.Join(workDir, "platforms", "android", "cdv-gradle-config.json"), "{}")
+		writeTestFile(t, filepath.Join(workDir, "platforms", "ios", "ios.json"), "{}")
+
+		items, err := platformsCache(workDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, filepath.Join(workDir, "platforms", cacheKeyFileName), storedKey(items.key))
+		return workDir
+	}
+	exists := func(pth string) bool {
+		_, err := os.Stat(pth)
+		return err == nil
+	}
+
+	t.Run("outdated cache", func(t *testing.T) {
+		workDir := setup(t, func(string) string { return "outdated" })
+		if err := invalidatePlatformsCache(workDir, []string{"android"}); err != nil {
+			t.Fatalf("invalidatePlatformsCache() error = %v", err)
+		}
+		if exists(filepath.Join(workDir, "platforms")) || exists(filepath.Join(workDir, "plugins")) {
+			t.Errorf("invalidatePlatformsCache() kept the outdated platforms and plugins dirs")
+		}
+	})
+
+	t.Run("incomplete platform", func(t *testing.T) {
+		workDir := setup(t, func(key string) string { return key })
+		if err := invalidatePlatformsCache(workDir, []string{"android", "ios"}); err != nil {
+			t.Fatalf("invalidatePlatformsCache() error = %v", err)
+		}
+		if !exists(filepath.Join(workDir, "platforms", "android")) {
+			t.Errorf("invalidatePlatformsCache() removed the complete android platform")
+		}
+		if exists(filepath.Join(workDir, "platforms", "ios")) {
+			t.Errorf("invalidatePlatformsCache() kept the incomplete ios platform")
+		}
+		if exists(filepath.Join(workDir, "platforms", cacheKeyFileName)) {
+			t.Errorf("invalidatePlatformsCache() kept the cache key")
+		}
+	})
+
+	t.Run("matching cache", func(t *testing.T) {
+		workDir := setup(t, func(key string) string { return key })
+		if err := invalidatePlatformsCache(workDir, []string{"android"}); err != nil {
+			t.Fatalf("invalidatePlatformsCache() error = %v", err)
+		}
+		if !exists(filepath.Join(workDir, "platforms", cacheKeyFileName)) || !exists(filepath.Join(workDir, "plugins")) {
+			t.Errorf("invalidatePlatformsCache() removed the matching cache")
+		}
+	})
+}
+
+func Test_platformsCache_excludesBuildOutputs(t *testing.T) {
+	workDir := t.TempDir()
+	writeTestFile(t, filepath.Join(workDir, "config.xml"), "<widget/>")
+
+	items, err := platformsCache(workDir)
+	if err != nil {
+		t.Fatalf("platformsCache() error = %v", err)
+	}
+
+	platformsDir := filepath.Join(workDir, "platforms")
+	want := []string{
+		filepath.Join(platformsDir, "*", "build"),
+		filepath.Join(platformsDir, "android", "app", "build"),
+		filepath.Join(platformsDir, "android", "CordovaLib", "build"),
+		filepath.Join(platformsDir, "android", ".gradle"),
+	}
+	if !reflect.DeepEqual(items.exclude, want) {
+		t.Errorf("platformsCache() exclude = %v, want %v", items.exclude, want)
+	}
+}
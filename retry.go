@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-utils/errorutil"
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// retrier runs an action again on failure, doubling the wait time after each attempt
+type retrier struct {
+	retries int
+	wait    time.Duration
+	sleep   func(time.Duration)
+}
+
+func newRetrier(retries int, waitSeconds int) retrier {
+	return retrier{retries: retries, wait: time.Duration(waitSeconds) * time.Second, sleep: time.Sleep}
+}
+
+// do runs the action until it succeeds or the retries run out.
+// The action returns the reason to retry its failure, a failure without a reason is not retried.
+func (r retrier) do(name string, action func() (string, error)) error {
+	wait := r.wait
+	for attempt := 1; ; attempt++ {
+		reason, err := action()
+		if err == nil || reason == "" || attempt > r.retries {
+			return err
+		}
+
+		log.Warnf("%s failed (%s), retrying in %s (retry %d/%d)", name, reason, wait, attempt, r.retries)
+		r.sleep(wait)
+		wait *= 2
+	}
+}
+
+// transientFailureSignatures are the outputs of network and registry failures, which usually succeed on a retry
+var transientFailureSignatures = []struct {
+	pattern *regexp.Regexp
+	reason  string
+}{
+	{regexp.MustCompile(`(?i)connection reset|ECONNRESET`), "connection reset"},
+	{regexp.MustCompile(`(?i)(connect|read) timed out|ETIMEDOUT|ESOCKETTIMEDOUT`), "network timeout"},
+	{regexp.MustCompile(`EAI_AGAIN|(?i)temporary failure in name resolution`), "DNS lookup failed"},
+	{regexp.MustCompile(`\bE5\d\d\b|(?i)status code 5\d\d|\b50[234] (Bad Gateway|Service Unavailable|Gateway Time-?out)`), "registry server error"},
+	{regexp.MustCompile(`Could not (GET|HEAD) '`), "dependency download failed"},
+}
+
+// clientErrorStatusPattern matches the 4xx responses (like an unauthorized repository), which are not fixed by a retry
+var clientErrorStatusPattern = regexp.MustCompile(`(?i)status code 4\d\d`)
+
+// transientFailureReason returns the reason and the matching line of the first transient failure in the output,
+// an empty string if the output has no transient failure
+func transientFailureReason(out string) string {
+	lines := strings.Split(out, "\n")
+	for _, signature := range transientFailureSignatures {
+		for _, line := range lines {
+			if !signature.pattern.MatchString(line) || clientErrorStatusPattern.MatchString(line) {
+				continue
+			}
+
+			line = strings.TrimSpace(line)
+			if len(line) > 200 {
+				line = line[:200] + "..."
+			}
+			return fmt.Sprintf("%s: %s", signature.reason, line)
+		}
+	}
+	return ""
+}
+
+// installRetryReason returns the reason to retry a failed global install.
+// Failures with a known cause (missing version, permission or setup issues) and commands which could not start are not retried.
+func installRetryReason(out string, err error) string {
+	if reason := transientFailureReason(out); reason != "" {
+		return reason
+	}
+
+	exitCode, _ := errorutil.CmdExitCodeFromError(err)
+	if exitCode == -1 {
+		return ""
+	}
+	for _, permanent := range []string{"E404", "ERR_PNPM_NO_MATCHING_VERSION", "Couldn't find any versions", "No version matching",
+		"ERR_PNPM_NO_GLOBAL_BIN_DIR", "EACCES"} {
+		if strings.Contains(out, permanent) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("exit code %d", exitCode)
+}
+
+// outputRecorder records the output of a command, it can be written by the stdout and stderr copying goroutines concurrently
+type outputRecorder struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (r *outputRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+func (r *outputRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.String()
+}
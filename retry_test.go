@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_retrier_do(t *testing.T) {
+	tests := []struct {
+		name         string
+		retries      int
+		reasons      []string
+		wantAttempts int
+		wantWaits    []time.Duration
+		wantErr      bool
+	}{
+		{
+			name:         "succeeds",
+			retries:      2,
+			reasons:      nil,
+			wantAttempts: 1,
+		},
+		{
+			name:         "succeeds on retry",
+			retries:      3,
+			reasons:      []string{"connection reset", "connection reset"},
+			wantAttempts: 3,
+			wantWaits:    []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:         "retries run out",
+			retries:      2,
+			reasons:      []string{"connection reset", "connection reset", "connection reset"},
+			wantAttempts: 3,
+			wantWaits:    []time.Duration{time.Second, 2 * time.Second},
+			wantErr:      true,
+		},
+		{
+			name:         "not retryable",
+			retries:      2,
+			reasons:      []string{""},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "no retries",
+			retries:      0,
+			reasons:      []string{"connection reset"},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var waits []time.Duration
+			r := retrier{retries: tt.retries, wait: time.Second, sleep: func(d time.Duration) { waits = append(waits, d) }}
+
+			attempts := 0
+			err := r.do("test", func() (string, error) {
+				attempts++
+				if attempts > len(tt.reasons) {
+					return "", nil
+				}
+				return tt.reasons[attempts-1], errors.New("failed")
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("do() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if !reflect.DeepEqual(waits, tt.wantWaits) {
+				t.Errorf("do() waits = %v, want %v", waits, tt.wantWaits)
+			}
+		})
+	}
+}
+
+func Test_transientFailureReason(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want string
+	}{
+		{
+			name: "gradle download",
+			out:  "> Could not resolve com.android.tools.build:gradle:8.3.0.\n   > Could not GET 'https://dl.google.com/dl/android/maven2/gradle-8.3.0.pom'. Received status code 502 from server: Bad Gateway",
+			want: "registry server error: > Could not GET 'https://dl.google.com/dl/android/maven2/gradle-8.3.0.pom'. Received status code 502 from server: Bad Gateway",
+		},
+		{
+			name: "gradle download without status",
+			out:  "   > Could not GET 'https://repo.maven.apache.org/maven2/a.pom'.\n      > Remote host terminated the handshake",
+			want: "dependency download failed: > Could not GET 'https://repo.maven.apache.org/maven2/a.pom'.",
+		},
+		{
+			name: "connection reset",
+			out:  "npm ERR! code ECONNRESET\nnpm ERR! network aborted",
+			want: "connection reset: npm ERR! code ECONNRESET",
+		},
+		{
+			name: "registry 5xx",
+			out:  "npm ERR! code E503\nnpm ERR! 503 Service Unavailable - GET https://registry.npmjs.org/cordova",
+			want: "registry server error: npm ERR! code E503",
+		},
+		{
+			name: "unauthorized repository",
+			out:  "> Could not GET 'https://maven.example.com/a.pom'. Received status code 401 from server: Unauthorized",
+			want: "",
+		},
+		{
+			name: "compile error",
+			out:  "error: cannot find symbol\nBUILD FAILED in 12s",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transientFailureReason(tt.out); got != tt.want {
+				t.Errorf("transientFailureReason() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_installRetryReason(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+	notStartedErr := exec.Command("/nonexistent/npm").Run()
+
+	tests := []struct {
+		name string
+		out  string
+		err  error
+		want string
+	}{
+		{
+			name: "transient failure",
+			out:  "npm ERR! code ETIMEDOUT",
+			err:  exitErr,
+			want: "network timeout: npm ERR! code ETIMEDOUT",
+		},
+		{
+			name: "unknown failure",
+			out:  "npm ERR! unexpected end of JSON input",
+			err:  exitErr,
+			want: "exit code 1",
+		},
+		{
+			name: "missing version",
+			out:  "npm ERR! code ETARGET\nnpm ERR! 404 No matching version\nnpm ERR! code E404",
+			err:  exitErr,
+		},
+		{
+			name: "not writable",
+			out:  "npm ERR! code EACCES",
+			err:  exitErr,
+		},
+		{
+			name: "command not started",
+			err:  notStartedErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := installRetryReason(tt.out, tt.err); got != tt.want {
+				t.Errorf("installRetryReason() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
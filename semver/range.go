@@ -0,0 +1,271 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Range is a set of version requirements in the npm range syntax, for example "^12", "~11.1", "1.x",
+// ">=10.0.0 <12", "1.2.3 - 2.3" or "^11 || ^12".
+type Range struct {
+	raw string
+	// sets are alternatives (joined by ||), a version is in the range if it satisfies every comparator of a set
+	sets [][]comparator
+}
+
+type comparator struct {
+	op      string
+	version Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+// any matches every version, none matches no versions
+var (
+	anyComparator  = comparator{op: ">=", version: Version{}}
+	noneComparator = comparator{op: "<", version: Version{Prerelease: "0"}}
+)
+
+// ParseRange parses an npm style version range
+func ParseRange(s string) (Range, error) {
+	r := Range{raw: s}
+	for _, set := range strings.Split(s, "||") {
+		comparators, err := parseComparatorSet(set)
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid version range (%s): %s", s, err)
+		}
+		r.sets = append(r.sets, comparators)
+	}
+	return r, nil
+}
+
+func parseComparatorSet(set string) ([]comparator, error) {
+	var fields []string
+	for _, field := range strings.Fields(set) {
+		// join operators separated from their version, like ">= 1.2.3"
+		if len(fields) > 0 && isOperator(fields[len(fields)-1]) {
+			fields[len(fields)-1] += field
+			continue
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return []comparator{anyComparator}, nil
+	}
+
+	var comparators []comparator
+	for i := 0; i < len(fields); i++ {
+		// hyphen range: 1.2.3 - 2.3.4
+		if i+2 < len(fields) && fields[i+1] == "-" {
+			hyphen, err := hyphenComparators(fields[i], fields[i+2])
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, hyphen...)
+			i += 2
+			continue
+		}
+
+		parsed, err := parseComparator(fields[i])
+		if err != nil {
+			return nil, err
+		}
+		comparators = append(comparators, parsed...)
+	}
+	return comparators, nil
+}
+
+func isOperator(s string) bool {
+	switch s {
+	case "<", "<=", ">", ">=", "=", "^", "~":
+		return true
+	}
+	return false
+}
+
+// parsePartial parses a version with optionally missing or wildcard (x, X, *) components,
+// returns the version with the missing components set to 0 and the number of specified components
+func parsePartial(s string) (Version, int, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+
+	var version Version
+	if i := strings.Index(s, "-"); i >= 0 {
+		version.Prerelease = s[i+1:]
+		s = s[:i]
+	}
+
+	components := strings.Split(s, ".")
+	if len(components) > 3 {
+		return Version{}, 0, fmt.Errorf("invalid version: %s", s)
+	}
+
+	numbers := make([]int, 3)
+	specified := 0
+	wildcard := false
+	for _, component := range components {
+		if component == "x" || component == "X" || component == "*" || (component == "" && len(components) == 1) {
+			wildcard = true
+			continue
+		}
+		if wildcard {
+			return Version{}, 0, fmt.Errorf("invalid version: %s", s)
+		}
+
+		n, err := strconv.Atoi(component)
+		if err != nil || n < 0 {
+			return Version{}, 0, fmt.Errorf("invalid version: %s", s)
+		}
+		numbers[specified] = n
+		specified++
+	}
+
+	version.Major, version.Minor, version.Patch = numbers[0], numbers[1], numbers[2]
+	if specified < 3 {
+		version.Prerelease = ""
+	}
+	return version, specified, nil
+}
+
+// nextUpperBound returns the lowest version above the partial version's range, for example 1.3.0 for 1.2.x
+func nextUpperBound(v Version, specified int) Version {
+	switch specified {
+	case 1:
+		return Version{Major: v.Major + 1}
+	case 2:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+func parseComparator(s string) ([]comparator, error) {
+	op := ""
+	for _, candidate := range []string{"<=", ">=", "<", ">", "=", "^", "~"} {
+		if strings.HasPrefix(s, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	v, specified, err := parsePartial(strings.TrimPrefix(s, op))
+	if err != nil {
+		return nil, err
+	}
+
+	if specified == 0 {
+		switch op {
+		case "<", ">":
+			return []comparator{noneComparator}, nil
+		}
+		return []comparator{anyComparator}, nil
+	}
+
+	switch op {
+	case "^":
+		upper := Version{Major: v.Major + 1}
+		switch {
+		case v.Major > 0 || specified == 1:
+		case v.Minor > 0 || specified == 2:
+			upper = Version{Minor: v.Minor + 1}
+		default:
+			upper = Version{Patch: v.Patch + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case "~":
+		upper := Version{Major: v.Major + 1}
+		if specified > 1 {
+			upper = Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case ">":
+		if specified < 3 {
+			return []comparator{{">=", nextUpperBound(v, specified)}}, nil
+		}
+		return []comparator{{">", v}}, nil
+	case ">=", "<":
+		return []comparator{{op, v}}, nil
+	case "<=":
+		if specified < 3 {
+			return []comparator{{"<", nextUpperBound(v, specified)}}, nil
+		}
+		return []comparator{{"<=", v}}, nil
+	}
+
+	// exact or partial version, like 1.2.3 or 1.x
+	if specified < 3 {
+		return []comparator{{">=", v}, {"<", nextUpperBound(v, specified)}}, nil
+	}
+	return []comparator{{"=", v}}, nil
+}
+
+func hyphenComparators(from, to string) ([]comparator, error) {
+	lower, _, err := parsePartial(from)
+	if err != nil {
+		return nil, err
+	}
+
+	upper, specified, err := parsePartial(to)
+	if err != nil {
+		return nil, err
+	}
+
+	comparators := []comparator{{">=", lower}}
+	switch {
+	case specified == 0:
+	case specified < 3:
+		comparators = append(comparators, comparator{"<", nextUpperBound(upper, specified)})
+	default:
+		comparators = append(comparators, comparator{"<=", upper})
+	}
+	return comparators, nil
+}
+
+// Contains returns true if the version satisfies the range.
+// Like npm, prerelease versions only satisfy a range, which mentions a prerelease of the same major.minor.patch version.
+func (r Range) Contains(v Version) bool {
+	for _, set := range r.sets {
+		if setContains(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func setContains(set []comparator, v Version) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+
+	if v.Prerelease == "" {
+		return true
+	}
+	for _, c := range set {
+		if c.version.Prerelease != "" && c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// String ...
+func (r Range) String() string {
+	return r.raw
+}
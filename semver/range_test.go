@@ -0,0 +1,68 @@
+package semver
+
+import "testing"
+
+func TestRange_Contains(t *testing.T) {
+	tests := []struct {
+		rng     string
+		version string
+		want    bool
+	}{
+		{"^12", "12.0.0", true},
+		{"^12", "12.3.1", true},
+		{"^12", "13.0.0", false},
+		{"^12", "11.9.9", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~11.1", "11.1.7", true},
+		{"~11.1", "11.2.0", false},
+		{"~11.1.2", "11.1.1", false},
+		{"~11", "11.9.0", true},
+		{"11.x", "11.4.0", true},
+		{"11.2.x", "11.3.0", false},
+		{"*", "1.0.0", true},
+		{"", "12.0.0", true},
+		{"12.0.0", "12.0.0", true},
+		{"=12.0.0", "12.0.1", false},
+		{"v12.0.0", "12.0.0", true},
+		{">=10.0.0 <12", "11.5.0", true},
+		{">=10.0.0 <12", "12.0.0", false},
+		{">= 10 < 12", "10.0.0", true},
+		{">11", "11.9.0", false},
+		{">11", "12.0.0", true},
+		{">11.0.0", "11.0.1", true},
+		{"<=11.1", "11.1.9", true},
+		{"<=11.1", "11.2.0", false},
+		{"10.0.0 - 11.2", "11.2.5", true},
+		{"10.0.0 - 11.2", "11.3.0", false},
+		{"10.0.0 - 11.2.0", "11.2.0", true},
+		{"^10 || ^12", "12.1.0", true},
+		{"^10 || ^12", "11.0.0", false},
+		{"^12", "12.1.0-nightly.1", false},
+		{"^12.1.0-nightly.0", "12.1.0-nightly.1", true},
+		{"^12.1.0-nightly.0", "12.2.0-nightly.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rng+" "+tt.version, func(t *testing.T) {
+			r, err := ParseRange(tt.rng)
+			if err != nil {
+				t.Fatalf("ParseRange() error = %v", err)
+			}
+			if got := r.Contains(MustParse(tt.version)); got != tt.want {
+				t.Errorf("Range(%s).Contains(%s) = %v, want %v", tt.rng, tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRange_invalid(t *testing.T) {
+	for _, rng := range []string{"latest", "^1.2.3.4", ">=a.b", "1.2 - x.y"} {
+		t.Run(rng, func(t *testing.T) {
+			if _, err := ParseRange(rng); err == nil {
+				t.Errorf("ParseRange(%s) expected error", rng)
+			}
+		})
+	}
+}
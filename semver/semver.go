@@ -0,0 +1,100 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version, see https://semver.org
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// Parse parses a version string.
+// A leading "v" or "=" and build metadata are ignored, missing minor and patch components default to 0,
+// so "v16", "16.13" and "16.13.0" are all accepted.
+func Parse(s string) (Version, error) {
+	original := s
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "=")
+	s = strings.TrimPrefix(s, "v")
+
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+
+	var version Version
+	if i := strings.Index(s, "-"); i >= 0 {
+		version.Prerelease = s[i+1:]
+		s = s[:i]
+	}
+
+	components := strings.Split(s, ".")
+	if len(components) > 3 {
+		return Version{}, fmt.Errorf("invalid version: %s", original)
+	}
+
+	numbers := make([]int, 3)
+	for i, component := range components {
+		n, err := strconv.Atoi(component)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version: %s", original)
+		}
+		numbers[i] = n
+	}
+
+	version.Major, version.Minor, version.Patch = numbers[0], numbers[1], numbers[2]
+	return version, nil
+}
+
+// MustParse is like Parse but panics if the version can not be parsed.
+func MustParse(s string) Version {
+	version, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return version
+}
+
+// Compare returns -1, 0 or 1 if v is lower than, equal to or greater than other.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] < pair[1] {
+			return -1
+		}
+		if pair[0] > pair[1] {
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	case v.Prerelease < other.Prerelease:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// LessThan ...
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// String ...
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
@@ -0,0 +1,55 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"6.2.0", "6.2.0", false},
+		{"v16", "16.0.0", false},
+		{"16.13", "16.13.0", false},
+		{"=7.0.0-nightly.1+sha.abc", "7.0.0-nightly.1", false},
+		{" 12.0.1\n", "12.0.1", false},
+		{"1.2.3.4", "", true},
+		{"latest", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		v     string
+		other string
+		want  int
+	}{
+		{"6.0.0", "6.0.0", 0},
+		{"5.1.1", "6.0.0", -1},
+		{"6.0.10", "6.0.9", 1},
+		{"7.0.0-rc.1", "7.0.0", -1},
+		{"7.0.0", "7.0.0-rc.1", 1},
+		{"7.0.0-rc.1", "7.0.0-rc.2", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.v+" vs "+tt.other, func(t *testing.T) {
+			if got := MustParse(tt.v).Compare(MustParse(tt.other)); got != tt.want {
+				t.Errorf("Compare() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const sizeReportFileName = "cordova-archive-size-report.json"
+
+const bytesPerMB = 1024 * 1024
+
+// Size report categories
+const (
+	wwwCategory        = "www"
+	nativeLibsCategory = "native_libs"
+	dexCategory        = "dex"
+	resourcesCategory  = "resources"
+	frameworksCategory = "frameworks"
+	otherCategory      = "other"
+)
+
+var sizeCategories = []string{wwwCategory, nativeLibsCategory, dexCategory, resourcesCategory, frameworksCategory, otherCategory}
+
+// iosResourceExts lists the file and directory extensions counted as resources in an ipa
+var iosResourceExts = []string{".car", ".nib", ".storyboardc", ".lproj", ".png", ".jpg", ".jpeg", ".strings"}
+
+// categorySize is the size of the entries of a category in an artifact
+type categorySize struct {
+	CompressedSize   int64 `json:"compressed_size"`
+	UncompressedSize int64 `json:"uncompressed_size"`
+}
+
+// artifactSize is the size breakdown of an exported artifact.
+// Native libs are reported per ABI, as native_libs/<abi>.
+type artifactSize struct {
+	Path       string                  `json:"path"`
+	Type       string                  `json:"type"`
+	Size       int64                   `json:"size"`
+	Categories map[string]categorySize `json:"categories"`
+}
+
+// sizeReport lists the size breakdown of the exported apks, aabs and ipas
+type sizeReport struct {
+	Artifacts []artifactSize `json:"artifacts"`
+}
+
+// sizeBudgets holds the size limits in bytes
+type sizeBudgets struct {
+	// maxArtifactSize limits the size of each artifact, 0 means no limit
+	maxArtifactSize int64
+	// categories limits the size of a category (e.g. www, native_libs, native_libs/arm64-v8a) in each artifact
+	categories map[string]int64
+}
+
+// parseSizeBudgets parses the max artifact size and the category=MB lines of the category budgets
+func parseSizeBudgets(maxArtifactSizeMB float64, categoryBudgets []string) (sizeBudgets, error) {
+	if maxArtifactSizeMB < 0 {
+		return sizeBudgets{}, fmt.Errorf("invalid max artifact size: %v", maxArtifactSizeMB)
+	}
+
+	budgets := sizeBudgets{
+		maxArtifactSize: int64(maxArtifactSizeMB * bytesPerMB),
+		categories:      map[string]int64{},
+	}
+	for _, line := range categoryBudgets {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		split := strings.SplitN(line, "=", 2)
+		if len(split) != 2 {
+			return sizeBudgets{}, fmt.Errorf("invalid size budget (%s), expected format: category=MB", line)
+		}
+
+		category := strings.TrimSpace(split[0])
+		if !isSizeCategory(category) {
+			return sizeBudgets{}, fmt.Errorf("unknown size budget category (%s), available categories: %s, native_libs/<abi>", category, strings.Join(sizeCategories, ", "))
+		}
+
+		mb, err := strconv.ParseFloat(strings.TrimSpace(split[1]), 64)
+		if err != nil || mb < 0 {
+			return sizeBudgets{}, fmt.Errorf("invalid size budget (%s), expected format: category=MB", line)
+		}
+		budgets.categories[category] = int64(mb * bytesPerMB)
+	}
+	return budgets, nil
+}
+
+func isSizeCategory(category string) bool {
+	for _, c := range sizeCategories {
+		if category == c {
+			return true
+		}
+	}
+	return strings.HasPrefix(category, nativeLibsCategory+"/")
+}
+
+// measureArtifact opens an apk, aab or ipa and sums the size of its entries by category
+func measureArtifact(pth, artifactType string) (artifactSize, error) {
+	info, err := os.Stat(pth)
+	if err != nil {
+		return artifactSize{}, err
+	}
+
+	zipReader, err := zip.OpenReader(pth)
+	if err != nil {
+		return artifactSize{}, err
+	}
+	defer func() {
+		if err := zipReader.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", pth, err)
+		}
+	}()
+
+	size := artifactSize{
+		Path:       pth,
+		Type:       artifactType,
+		Size:       info.Size(),
+		Categories: map[string]categorySize{},
+	}
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		category := sizeCategoryOf(file.Name, artifactType)
+		categorySize := size.Categories[category]
+		categorySize.CompressedSize += int64(file.CompressedSize64)
+		categorySize.UncompressedSize += int64(file.UncompressedSize64)
+		size.Categories[category] = categorySize
+	}
+	return size, nil
+}
+
+// sizeCategoryOf returns the size category of an apk, aab or ipa entry
+func sizeCategoryOf(entryName, artifactType string) string {
+	components := strings.Split(entryName, "/")
+
+	switch artifactType {
+	case aabArtifact:
+		// module/assets/www/..., module/lib/<abi>/..., module/dex/..., module/res/..., module/resources.pb
+		if len(components) < 2 {
+			return otherCategory
+		}
+		components = components[1:]
+		if components[0] == "dex" {
+			return dexCategory
+		}
+		if len(components) == 1 && components[0] == "resources.pb" {
+			return resourcesCategory
+		}
+		fallthrough
+	case apkArtifact:
+		switch {
+		case len(components) > 2 && components[0] == "assets" && components[1] == "www":
+			return wwwCategory
+		case len(components) > 2 && components[0] == "lib":
+			return nativeLibsCategory + "/" + components[1]
+		case len(components) == 1 && path.Ext(components[0]) == ".dex":
+			return dexCategory
+		case len(components) > 1 && components[0] == "res",
+			len(components) == 1 && components[0] == "resources.arsc":
+			return resourcesCategory
+		}
+	case ipaArtifact:
+		// Payload/<app>.app/...
+		if len(components) < 3 || components[0] != "Payload" {
+			return otherCategory
+		}
+		components = components[2:]
+		switch {
+		case len(components) > 1 && components[0] == "www":
+			return wwwCategory
+		case len(components) > 1 && components[0] == "Frameworks":
+			return frameworksCategory
+		}
+		for _, component := range components {
+			for _, ext := range iosResourceExts {
+				if strings.EqualFold(path.Ext(component), ext) {
+					return resourcesCategory
+				}
+			}
+		}
+	}
+	return otherCategory
+}
+
+// categoryCompressedSize returns the compressed size of a category, native_libs sums up every ABI
+func (s artifactSize) categoryCompressedSize(category string) int64 {
+	if category != nativeLibsCategory {
+		return s.Categories[category].CompressedSize
+	}
+
+	var total int64
+	for name, size := range s.Categories {
+		if strings.HasPrefix(name, nativeLibsCategory+"/") {
+			total += size.CompressedSize
+		}
+	}
+	return total
+}
+
+// check returns the budgets exceeded by the artifact
+func (b sizeBudgets) check(size artifactSize) []string {
+	var violations []string
+	if b.maxArtifactSize > 0 && size.Size > b.maxArtifactSize {
+		violations = append(violations, fmt.Sprintf("%s is %s, exceeding the max artifact size of %s", filepath.Base(size.Path), formatMB(size.Size), formatMB(b.maxArtifactSize)))
+	}
+
+	var categories []string
+	for category := range b.categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		if categorySize := size.categoryCompressedSize(category); categorySize > b.categories[category] {
+			violations = append(violations, fmt.Sprintf("%s of %s is %s, exceeding the budget of %s", category, filepath.Base(size.Path), formatMB(categorySize), formatMB(b.categories[category])))
+		}
+	}
+	return violations
+}
+
+// logSizeTable prints the size breakdown of an artifact, largest categories first
+func logSizeTable(size artifactSize) {
+	log.Printf("%s (%s): %s", filepath.Base(size.Path), size.Type, formatMB(size.Size))
+
+	var categories []string
+	for category := range size.Categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		ci, cj := size.Categories[categories[i]], size.Categories[categories[j]]
+		if ci.CompressedSize != cj.CompressedSize {
+			return ci.CompressedSize > cj.CompressedSize
+		}
+		return categories[i] < categories[j]
+	})
+
+	log.Printf("  %-28s %12s %14s", "category", "compressed", "uncompressed")
+	for _, category := range categories {
+		log.Printf("  %-28s %12s %14s", category, formatMB(size.Categories[category].CompressedSize), formatMB(size.Categories[category].UncompressedSize))
+	}
+}
+
+func formatMB(size int64) string {
+	return fmt.Sprintf("%.2f MB", float64(size)/bytesPerMB)
+}
+
+// write writes the size report into the given dir
+func (r sizeReport) write(dir string) (string, error) {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	pth := filepath.Join(dir, sizeReportFileName)
+	if err := os.WriteFile(pth, content, 0600); err != nil {
+		return "", err
+	}
+	return pth, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_sizeCategoryOf(t *testing.T) {
+	tests := []struct {
+		entry        string
+		artifactType string
+		want         string
+	}{
+		{"assets/www/index.html", apkArtifact, wwwCategory},
+		{"assets/www/node_modules/lodash/lodash.js", apkArtifact, wwwCategory},
+		{"lib/arm64-v8a/libsqlc.so", apkArtifact, "native_libs/arm64-v8a"},
+		{"classes.dex", apkArtifact, dexCategory},
+		{"classes2.dex", apkArtifact, dexCategory},
+		{"res/drawable/icon.png", apkArtifact, resourcesCategory},
+		{"resources.arsc", apkArtifact, resourcesCategory},
+		{"AndroidManifest.xml", apkArtifact, otherCategory},
+		{"base/assets/www/index.html", aabArtifact, wwwCategory},
+		{"base/lib/x86_64/libsqlc.so", aabArtifact, "native_libs/x86_64"},
+		{"base/dex/classes.dex", aabArtifact, dexCategory},
+		{"base/res/layout/main.xml", aabArtifact, resourcesCategory},
+		{"base/resources.pb", aabArtifact, resourcesCategory},
+		{"BundleConfig.pb", aabArtifact, otherCategory},
+		{"Payload/App.app/www/index.html", ipaArtifact, wwwCategory},
+		{"Payload/App.app/Frameworks/Sentry.framework/Sentry", ipaArtifact, frameworksCategory},
+		{"Payload/App.app/Assets.car", ipaArtifact, resourcesCategory},
+		{"Payload/App.app/en.lproj/Localizable.strings", ipaArtifact, resourcesCategory},
+		{"Payload/App.app/CDVLaunchScreen.storyboardc/Info.plist", ipaArtifact, resourcesCategory},
+		{"Payload/App.app/App", ipaArtifact, otherCategory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			if got := sizeCategoryOf(tt.entry, tt.artifactType); got != tt.want {
+				t.Errorf("sizeCategoryOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseSizeBudgets(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxArtifactSize float64
+		categories      []string
+		wantErr         bool
+	}{
+		{"No budgets", 0, nil, false},
+		{"Valid budgets", 50, []string{"www=20", " native_libs/arm64-v8a = 15.5", ""}, false},
+		{"Unknown category", 0, []string{"fonts=2"}, true},
+		{"Missing size", 0, []string{"www"}, true},
+		{"Negative size", 0, []string{"www=-1"}, true},
+		{"Negative max size", -1, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSizeBudgets(tt.maxArtifactSize, tt.categories); (err != nil) != tt.wantErr {
+				t.Errorf("parseSizeBudgets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_measureArtifact(t *testing.T) {
+	apkPth := filepath.Join(t.TempDir(), "app-release.apk")
+	f, err := os.Create(apkPth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipWriter := zip.NewWriter(f)
+	for name, size := range map[string]int{
+		"assets/www/index.html":         3 * bytesPerMB,
+		"lib/arm64-v8a/libsqlc.so":      bytesPerMB,
+		"lib/armeabi-v7a/libsqlc.so":    bytesPerMB,
+		"classes.dex":                   100,
+		"AndroidManifest.xml":           10,
+		"assets/www/cordova_plugins.js": 10,
+	} {
+		// stored, so compressed size equals uncompressed size
+		w, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(make([]byte, size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := measureArtifact(apkPth, apkArtifact)
+	if err != nil {
+		t.Fatalf("measureArtifact() error = %v", err)
+	}
+
+	if got := size.Categories[wwwCategory].UncompressedSize; got != 3*bytesPerMB+10 {
+		t.Errorf("www size = %d", got)
+	}
+	if got := size.categoryCompressedSize(nativeLibsCategory); got != 2*bytesPerMB {
+		t.Errorf("native_libs size = %d", got)
+	}
+	if got := size.Categories[dexCategory].CompressedSize; got != 100 {
+		t.Errorf("dex size = %d", got)
+	}
+
+	budgets, err := parseSizeBudgets(10, []string{"www=2", "native_libs/arm64-v8a=1", "native_libs=1.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations := budgets.check(size)
+	if len(violations) != 2 {
+		t.Errorf("check() = %v, want the www and native_libs budgets exceeded", violations)
+	}
+}
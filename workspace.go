@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/sliceutil"
+)
+
+// jsWorkspace is a Yarn, npm or pnpm workspace, its dependencies are hoisted to the root's node_modules
+type jsWorkspace struct {
+	root string
+	// packages are the member package dirs, matching the workspace patterns
+	packages []string
+}
+
+// findJSWorkspace looks for the workspace containing the project dir, in the dir and its parents up to the repository root.
+// Returns nil if the project is not a workspace member.
+func findJSWorkspace(projectDir string) (*jsWorkspace, error) {
+	projectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for dir := projectDir; ; dir = filepath.Dir(dir) {
+		patterns, err := workspacePatterns(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(patterns) > 0 {
+			packages, err := workspacePackages(dir, patterns)
+			if err != nil {
+				return nil, err
+			}
+
+			if dir == projectDir || sliceutil.IsStringInSlice(projectDir, packages) {
+				return &jsWorkspace{root: dir, packages: packages}, nil
+			}
+		}
+
+		if isRepositoryRoot, err := pathutil.IsPathExists(filepath.Join(dir, ".git")); err != nil {
+			return nil, err
+		} else if isRepositoryRoot || dir == filepath.Dir(dir) {
+			return nil, nil
+		}
+	}
+}
+
+// workspacePatterns returns the package patterns of the package.json workspaces field or the pnpm-workspace.yaml
+func workspacePatterns(dir string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var packageJSON struct {
+			Workspaces json.RawMessage `json:"workspaces"`
+		}
+		if err := json.Unmarshal(content, &packageJSON); err != nil {
+			return nil, err
+		}
+
+		if patterns := parseWorkspacesField(packageJSON.Workspaces); len(patterns) > 0 {
+			return patterns, nil
+		}
+	}
+
+	return readPnpmWorkspacePatterns(filepath.Join(dir, "pnpm-workspace.yaml"))
+}
+
+// parseWorkspacesField parses the package.json workspaces field,
+// either a list of patterns or an object with a packages list (Yarn classic)
+func parseWorkspacesField(workspaces json.RawMessage) []string {
+	if len(workspaces) == 0 {
+		return nil
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(workspaces, &patterns); err == nil {
+		return patterns
+	}
+
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(workspaces, &object); err == nil {
+		return object.Packages
+	}
+	return nil
+}
+
+// readPnpmWorkspacePatterns reads the items of the top level packages list of a pnpm-workspace.yaml
+func readPnpmWorkspacePatterns(pth string) ([]string, error) {
+	f, err := os.Open(pth)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", pth, err)
+		}
+	}()
+
+	var patterns []string
+	inPackages := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			inPackages = trimmed == "packages:"
+			continue
+		}
+
+		if inPackages && strings.HasPrefix(trimmed, "-") {
+			pattern := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			patterns = append(patterns, strings.Trim(pattern, `'"`))
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// workspacePackages returns the dirs with a package.json matching the workspace patterns, "!" patterns exclude dirs
+func workspacePackages(root string, patterns []string) ([]string, error) {
+	included := map[string]bool{}
+	var excluded []string
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			excluded = append(excluded, strings.TrimPrefix(pattern, "!"))
+			continue
+		}
+
+		// nested ** patterns are matched one level deep
+		matches, err := filepath.Glob(filepath.Join(root, strings.Replace(pattern, "**", "*", -1), "package.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			included[filepath.Dir(match)] = true
+		}
+	}
+
+	var packages []string
+	for dir := range included {
+		isExcluded := false
+		for _, pattern := range excluded {
+			if match, err := filepath.Match(filepath.Join(root, pattern), dir); err == nil && match {
+				isExcluded = true
+				break
+			}
+		}
+		if !isExcluded {
+			packages = append(packages, dir)
+		}
+	}
+	sort.Strings(packages)
+	return packages, nil
+}
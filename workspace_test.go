@@ -0,0 +1,130 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_findJSWorkspace(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		projectDir  string
+		wantRoot    string
+		wantPackage []string
+	}{
+		{
+			name: "npm workspaces",
+			files: map[string]string{
+				"package.json":             `{"workspaces": ["apps/*", "packages/*"]}`,
+				"apps/mobile/package.json": `{}`,
+				"packages/ui/package.json": `{}`,
+			},
+			projectDir:  "apps/mobile",
+			wantRoot:    ".",
+			wantPackage: []string{"apps/mobile", "packages/ui"},
+		},
+		{
+			name: "yarn classic workspaces object",
+			files: map[string]string{
+				"package.json":             `{"workspaces": {"packages": ["apps/*"], "nohoist": ["**/cordova*"]}}`,
+				"apps/mobile/package.json": `{}`,
+			},
+			projectDir:  "apps/mobile",
+			wantRoot:    ".",
+			wantPackage: []string{"apps/mobile"},
+		},
+		{
+			name: "pnpm workspace",
+			files: map[string]string{
+				"package.json":             `{}`,
+				"pnpm-workspace.yaml":      "packages:\n  - 'apps/*'\n  - \"!apps/legacy\"\n",
+				"apps/mobile/package.json": `{}`,
+				"apps/legacy/package.json": `{}`,
+			},
+			projectDir:  "apps/mobile",
+			wantRoot:    ".",
+			wantPackage: []string{"apps/mobile"},
+		},
+		{
+			name: "not a member",
+			files: map[string]string{
+				".git/HEAD":                `ref: refs/heads/main`,
+				"package.json":             `{"workspaces": ["packages/*"]}`,
+				"apps/mobile/package.json": `{}`,
+			},
+			projectDir: "apps/mobile",
+		},
+		{
+			name: "no workspace",
+			files: map[string]string{
+				".git/HEAD":    `ref: refs/heads/main`,
+				"package.json": `{"dependencies": {}}`,
+			},
+			projectDir: ".",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for pth, content := range tt.files {
+				writeTestFile(t, filepath.Join(root, pth), content)
+			}
+
+			got, err := findJSWorkspace(filepath.Join(root, tt.projectDir))
+			if err != nil {
+				t.Fatalf("findJSWorkspace() error = %v", err)
+			}
+
+			if tt.wantRoot == "" {
+				if got != nil {
+					t.Errorf("findJSWorkspace() = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("findJSWorkspace() = nil, want a workspace")
+			}
+
+			var wantPackages []string
+			for _, pkg := range tt.wantPackage {
+				wantPackages = append(wantPackages, filepath.Join(root, pkg))
+			}
+			if got.root != filepath.Join(root, tt.wantRoot) || !reflect.DeepEqual(got.packages, wantPackages) {
+				t.Errorf("findJSWorkspace() = %v, want root %v, packages %v", got, filepath.Join(root, tt.wantRoot), wantPackages)
+			}
+		})
+	}
+}
+
+func Test_jsDependencyCache_workspace(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "package.json"), `{"workspaces": ["apps/*", "packages/*"]}`)
+	writeTestFile(t, filepath.Join(root, "yarn.lock"), "lock")
+	writeTestFile(t, filepath.Join(root, "node_modules", "cordova", "package.json"), `{}`)
+	writeTestFile(t, filepath.Join(root, "apps", "mobile", "package.json"), `{}`)
+	writeTestFile(t, filepath.Join(root, "apps", "mobile", "node_modules", "cordova-android", "package.json"), `{}`)
+	writeTestFile(t, filepath.Join(root, "packages", "ui", "package.json"), `{}`)
+
+	got, err := jsDependencyCache(filepath.Join(root, "apps", "mobile"))
+	if err != nil {
+		t.Fatalf("jsDependencyCache() error = %v", err)
+	}
+
+	wantInclude := []string{filepath.Join(root, "node_modules"), filepath.Join(root, "apps", "mobile", "node_modules")}
+	if !reflect.DeepEqual(got.include, wantInclude) {
+		t.Errorf("jsDependencyCache() include = %v, want %v", got.include, wantInclude)
+	}
+	if got.keyDir != filepath.Join(root, "node_modules") {
+		t.Errorf("jsDependencyCache() keyDir = %v, want the hoisted node_modules", got.keyDir)
+	}
+
+	wantKey, err := cacheKey(filepath.Join(root, "yarn.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.key != wantKey {
+		t.Errorf("jsDependencyCache() key = %v, want the root lockfile's checksum %v", got.key, wantKey)
+	}
+}